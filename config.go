@@ -8,16 +8,132 @@ import (
 	"log"
 	"net"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Address struct {
-	IP       string `yaml:"ip"`
-	Port     int    `yaml:"port"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
-	Path     string `yaml:"path"`
+	IP       string  `yaml:"ip"`
+	Port     int     `yaml:"port"`
+	Username string  `yaml:"username"`
+	Password string  `yaml:"password"`
+	Path     string  `yaml:"path"`
+	Scheme   string  `yaml:"scheme,omitempty"`
+	TLS      *TLS    `yaml:"tls,omitempty"`
+	OTP      *OTP    `yaml:"otp,omitempty"`
+	Filter   *Filter `yaml:"filter,omitempty"`
+
+	Auth    *SSHAuth `yaml:"auth,omitempty"`
+	HostKey *HostKey `yaml:"host_key,omitempty"`
+
+	Paths []*SourcePath `yaml:"paths,omitempty"`
+
+	Overwrite string `yaml:"overwrite,omitempty"`
+	Resume    bool   `yaml:"resume,omitempty"`
+}
+
+type TLS struct {
+	CAFile      string `yaml:"ca_file,omitempty"`
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+	Insecure    bool   `yaml:"insecure,omitempty"`
+}
+
+type Share struct {
+	URL      string `yaml:"url"`
+	Password string `yaml:"password,omitempty"`
+	TLS      *TLS   `yaml:"tls,omitempty"`
+
+	SourcePath `yaml:",inline"`
+}
+
+type Photos struct {
+	Spaces []string `yaml:"spaces,omitempty"`
+	Albums []string `yaml:"albums,omitempty"`
+
+	SourcePath `yaml:",inline"`
+}
+
+type SourcePath struct {
+	Path    string   `yaml:"path"`
+	Dest    string   `yaml:"dest,omitempty"`
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Match 는 파일 이름이 include/exclude 규칙에 맞는지 확인 (대소문자 구분 없음)
+func (s *SourcePath) Match(name string) bool {
+	name = strings.ToLower(name)
+	if len(s.Include) != 0 {
+		included := false
+		for _, pattern := range s.Include {
+			if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, pattern := range s.Exclude {
+		if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalPath 는 원본 경로의 로컬 저장 경로를 반환
+func (s *SourcePath) LocalPath() string {
+	return filepath.Join(config.LocalPath, s.Path)
+}
+
+// RemotePath 는 로컬 파일에 해당하는 업로드 경로를 업로드 기본 경로 기준 상대 경로로 반환
+func (s *SourcePath) RemotePath(targetPath string) string {
+	relPath, _ := strings.CutPrefix(targetPath, s.LocalPath())
+	return filepath.Join(s.Dest, relPath)
+}
+
+type Filter struct {
+	Pattern   string   `yaml:"pattern,omitempty"`
+	Extension []string `yaml:"extension,omitempty"`
+	SizeFrom  uint64   `yaml:"size_from,omitempty"`
+	SizeTo    uint64   `yaml:"size_to,omitempty"`
+}
+
+type PostSync struct {
+	Action      string `yaml:"action"`
+	ArchivePath string `yaml:"archive_path,omitempty"`
+	Overwrite   bool   `yaml:"overwrite,omitempty"`
+}
+
+type BatchDownload struct {
+	MinFiles    int    `yaml:"min_files"`
+	MaxFileSize uint64 `yaml:"max_file_size"`
+	MaxSize     uint64 `yaml:"max_size"`
+}
+
+type SSHAuth struct {
+	Methods       []string `yaml:"methods,omitempty"`
+	KeyFile       string   `yaml:"key_file,omitempty"`
+	KeyPassphrase string   `yaml:"key_passphrase,omitempty"`
+	AgentSocket   string   `yaml:"agent_socket,omitempty"`
+}
+
+type HostKey struct {
+	Mode        string `yaml:"mode,omitempty"`
+	KnownHosts  string `yaml:"known_hosts,omitempty"`
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+}
+
+type OTP struct {
+	Secret          string `yaml:"secret,omitempty"`
+	DeviceName      string `yaml:"device_name,omitempty"`
+	DeviceTokenFile string `yaml:"device_token_file,omitempty"`
 }
 
 type DB struct {
@@ -33,11 +149,18 @@ type FileDB struct {
 }
 
 type Config struct {
-	DownloadType string   `yaml:"download_type"`
-	Synology     *Address `yaml:"synology,omitempty"`
+	DownloadType  string   `yaml:"download_type"`
+	Synology      *Address `yaml:"synology,omitempty"`
+	SynologyShare *Share   `yaml:"synology_share,omitempty"`
+
+	SynologyPhotos *Photos `yaml:"synology_photos,omitempty"`
 
-	UploadType string   `yaml:"upload_type"`
-	SSH        *Address `yaml:"ssh,omitempty"`
+	UploadType     string   `yaml:"upload_type"`
+	SSH            *Address `yaml:"ssh,omitempty"`
+	SynologyUpload *Address `yaml:"synology_upload,omitempty"`
+
+	PostSync      *PostSync      `yaml:"post_sync,omitempty"`
+	BatchDownload *BatchDownload `yaml:"batch_download,omitempty"`
 
 	DBType    string  `yaml:"db_type"`
 	YAML      *FileDB `yaml:"yaml,omitempty"`
@@ -46,6 +169,22 @@ type Config struct {
 	SpareSpace     uint64 `yaml:"spare_space"`
 	SyncCycle      int    `yaml:"sync_cycle"`
 	DownloadWorker int    `yaml:"download_worker"`
+	ListPageSize   int    `yaml:"list_page_size"`
+	VerifyChecksum bool   `yaml:"verify_checksum"`
+	MD5Timeout     int    `yaml:"md5_timeout"`
+
+	ChangeDetection string `yaml:"change_detection"`
+	PreserveTime    bool   `yaml:"preserve_time"`
+	IncrementalScan bool   `yaml:"incremental_scan"`
+	FullScanCycle   int    `yaml:"full_scan_cycle"`
+
+	ConnectTimeout        int `yaml:"connect_timeout"`
+	ResponseHeaderTimeout int `yaml:"response_header_timeout"`
+	IdleConnTimeout       int `yaml:"idle_conn_timeout"`
+
+	StallTimeout        int   `yaml:"stall_timeout"`
+	TransferBaseTimeout int   `yaml:"transfer_base_timeout"`
+	TransferMinRate     int64 `yaml:"transfer_min_rate"`
 
 	DownloadDelay      int `yaml:"download_delay"`
 	DownloadRetryDelay int `yaml:"download_retry_delay"`
@@ -57,16 +196,17 @@ type Config struct {
 }
 
 var defaultConfig = &Config{
-	DownloadType: "synology", // Download type(synology, skip(TBD), etc...(TBD))
+	DownloadType: "synology", // Download type(synology, synology_share, synology_photos, skip(TBD), etc...(TBD))
 	Synology: &Address{
 		IP:       "1.2.3.4", // FileStation IP address
 		Port:     5001,      // FileStation port
 		Username: "admin",   // FileStation account username
 		Password: "pass",    // FileStation account password
 		Path:     "/photo",  // FileStation path to download files
+		Scheme:   "https",   // FileStation scheme(http, https)
 	},
 
-	UploadType: "ssh", // Upload type(ssh, skip(TBD), etc...(TBD))
+	UploadType: "ssh", // Upload type(ssh, synology, skip(TBD), etc...(TBD))
 	SSH: &Address{
 		IP:       "192.168.0.100", // SSH IP address
 		Port:     22,              // SSH port
@@ -84,10 +224,26 @@ var defaultConfig = &Config{
 	SpareSpace:     1073741824,            // Spare space of upload filesystem(Byte)
 	SyncCycle:      12,                    // Sync cycle(Hour)
 	DownloadWorker: runtime.GOMAXPROCS(0), // Number of concurrent downloads(runtime.GOMAXPROCS(0))
+	ListPageSize:   1000,                  // Number of files per FileStation list request
+	VerifyChecksum: false,                 // Verify downloaded files with FileStation MD5
+	MD5Timeout:     600,                   // Maximum time to wait for FileStation MD5 of a file(Second)
+
+	ChangeDetection: "mtime", // Change detection(size, mtime, hash)
+	PreserveTime:    false,   // Apply source modification time to local and remote files
+	IncrementalScan: false,   // Search only files modified since the last scan
+	FullScanCycle:   168,     // Full scan cycle to catch deleted files(Hour)
+
+	ConnectTimeout:        10, // Synology connect timeout(Second)
+	ResponseHeaderTimeout: 60, // Synology response header timeout(Second)
+	IdleConnTimeout:       90, // Synology idle connection timeout(Second)
+
+	StallTimeout:        60,    // Abort a transfer when no bytes move for this time(Second)(-1: disable)
+	TransferBaseTimeout: 300,   // Base time allowed for each file transfer(Second)
+	TransferMinRate:     65536, // Minimum transfer rate to scale the per-file deadline(Byte/s)(-1: disable)
 
 	DownloadDelay:      10, // Download delay(Second)(TBD)
-	DownloadRetryDelay: 2,  // Download retry delay(Second)(TBD)
-	DownloadRetryCount: 10, // Download retry count(TBD)
+	DownloadRetryDelay: 2,  // Download retry delay(Second)
+	DownloadRetryCount: 10, // Download retry count
 
 	UploadDelay:      10, // Upload delay(Second)
 	UploadRetryDelay: 2,  // Upload retry delay(Second)
@@ -96,6 +252,9 @@ var defaultConfig = &Config{
 
 const defaultConfigPath = "./config.yaml"
 
+// ssh 호스트 키를 기록할 기본 known_hosts 파일 이름 (local_path 아래)
+const knownHostsFilename = "known_hosts"
+
 func initConfig(configPath string) (*Config, error) {
 	defaultConfig.LocalPath, _ = os.Getwd()
 	var result *Config
@@ -143,27 +302,38 @@ func makeDefaultConfig() error {
 func verifyConfig(config *Config) error {
 	// verify synology
 	if config.DownloadType == "synology" {
-		// verify ip address
-		if len(config.Synology.IP) == 0 {
-			return errors.New("synology ip address is required")
+		if err := verifySynologyAddress("synology", config.Synology); err != nil {
+			return err
 		}
-		// verify port number
-		if config.Synology.Port == 0 {
-			return errors.New("synology port is required")
+		// verify source paths
+		if err := verifySourcePaths(config.Synology); err != nil {
+			return err
 		}
-		if _, err := net.LookupPort("tcp", strconv.Itoa(config.Synology.Port)); err != nil {
-			return errors.New("invalid synology port number")
+		// verify filter
+		if filter := config.Synology.Filter; filter != nil {
+			if filter.SizeTo != 0 && filter.SizeFrom > filter.SizeTo {
+				return errors.New("synology filter size_from must not be greater than size_to")
+			}
+			for i, ext := range filter.Extension {
+				filter.Extension[i] = strings.TrimPrefix(strings.ToLower(ext), ".")
+			}
 		}
-		// verify username and password
-		if len(config.Synology.Username) == 0 {
-			return errors.New("synology username is required")
+	}
+
+	// verify synology share
+	if config.DownloadType == "synology_share" {
+		if err := verifySynologyShare(config); err != nil {
+			return err
 		}
-		if len(config.Synology.Password) == 0 {
-			return errors.New("synology password is required")
+	}
+
+	// verify synology photos
+	if config.DownloadType == "synology_photos" {
+		if err := verifySynologyAddress("synology", config.Synology); err != nil {
+			return err
 		}
-		// verify path
-		if len(config.Synology.Path) == 0 {
-			return errors.New("filestation path is required")
+		if err := verifySynologyPhotos(config); err != nil {
+			return err
 		}
 	}
 
@@ -184,8 +354,12 @@ func verifyConfig(config *Config) error {
 		if len(config.SSH.Username) == 0 {
 			return errors.New("ssh username is required")
 		}
-		if len(config.SSH.Password) == 0 {
-			return errors.New("ssh password is required")
+		if err := verifySSHAuth(config.SSH); err != nil {
+			return err
+		}
+		// verify host key
+		if err := verifySSHHostKey(config.SSH, config.LocalPath); err != nil {
+			return err
 		}
 		// verify path
 		if len(config.SSH.Path) == 0 {
@@ -193,6 +367,70 @@ func verifyConfig(config *Config) error {
 		}
 	}
 
+	// verify synology upload
+	if config.UploadType == "synology" {
+		if err := verifySynologyAddress("synology_upload", config.SynologyUpload); err != nil {
+			return err
+		}
+		// verify path
+		if len(config.SynologyUpload.Path) == 0 {
+			return errors.New("synology_upload filestation path is required")
+		}
+		// verify overwrite policy
+		switch protocol.OverwritePolicy(config.SynologyUpload.Overwrite) {
+		case "":
+			config.SynologyUpload.Overwrite = string(protocol.OverwriteSkip)
+		case protocol.Overwrite, protocol.OverwriteSkip, protocol.OverwriteError:
+		default:
+			return fmt.Errorf("invalid synology_upload overwrite policy: %s", config.SynologyUpload.Overwrite)
+		}
+	}
+
+	// verify post sync
+	if config.PostSync != nil {
+		switch config.PostSync.Action {
+		case "", "none":
+			config.PostSync.Action = "none"
+		case "move":
+			if len(config.PostSync.ArchivePath) == 0 {
+				return errors.New("post sync archive path is required for move action")
+			}
+		case "delete":
+		default:
+			return fmt.Errorf("invalid post sync action: %s", config.PostSync.Action)
+		}
+		if config.PostSync.Action != "none" && config.DownloadType != "synology" {
+			return errors.New("post sync requires synology download type")
+		}
+		// archive 경로가 원본 경로 안에 있으면 옮긴 파일을 다시 받게 됨
+		if config.PostSync.Action == "move" {
+			for _, source := range config.Synology.Paths {
+				if isSubPath(config.PostSync.ArchivePath, source.Path) {
+					return fmt.Errorf("post sync archive path %s must not be inside source path %s", config.PostSync.ArchivePath, source.Path)
+				}
+			}
+		}
+	}
+
+	// verify batch download (설정하지 않은 값은 기본값 사용)
+	if config.BatchDownload != nil {
+		if config.DownloadType != "synology" {
+			return errors.New("batch download requires synology download type")
+		}
+		if config.BatchDownload.MinFiles <= 0 {
+			config.BatchDownload.MinFiles = 20
+		}
+		if config.BatchDownload.MaxFileSize == 0 {
+			config.BatchDownload.MaxFileSize = 1048576
+		}
+		if config.BatchDownload.MaxSize == 0 {
+			config.BatchDownload.MaxSize = 104857600
+		}
+		if config.BatchDownload.MaxFileSize > config.BatchDownload.MaxSize {
+			return errors.New("batch download max file size must not be bigger than max size")
+		}
+	}
+
 	// verify yaml
 	if config.DBType == "yaml" {
 		if len(config.YAML.Filename) == 0 {
@@ -200,6 +438,52 @@ func verifyConfig(config *Config) error {
 		}
 	}
 
+	// verify change detection
+	switch config.ChangeDetection {
+	case "":
+		config.ChangeDetection = "mtime"
+	case "size", "mtime", "hash":
+	default:
+		return fmt.Errorf("invalid change detection: %s", config.ChangeDetection)
+	}
+
+	// verify full scan cycle
+	if config.IncrementalScan && config.FullScanCycle <= 0 {
+		return errors.New("full scan cycle is required for incremental scan")
+	}
+
+	// verify http timeouts (설정하지 않으면 기본값 사용)
+	if config.ConnectTimeout <= 0 {
+		config.ConnectTimeout = defaultConfig.ConnectTimeout
+	}
+	if config.ResponseHeaderTimeout <= 0 {
+		config.ResponseHeaderTimeout = defaultConfig.ResponseHeaderTimeout
+	}
+	if config.IdleConnTimeout <= 0 {
+		config.IdleConnTimeout = defaultConfig.IdleConnTimeout
+	}
+
+	// verify transfer limits (설정하지 않으면 기본값 사용, 음수면 사용 안 함)
+	if config.StallTimeout == 0 {
+		config.StallTimeout = defaultConfig.StallTimeout
+	}
+	if config.TransferBaseTimeout <= 0 {
+		config.TransferBaseTimeout = defaultConfig.TransferBaseTimeout
+	}
+	if config.TransferMinRate == 0 {
+		config.TransferMinRate = defaultConfig.TransferMinRate
+	}
+
+	// verify md5 timeout (설정하지 않으면 기본값 사용)
+	if config.MD5Timeout <= 0 {
+		config.MD5Timeout = defaultConfig.MD5Timeout
+	}
+
+	// verify list page size
+	if config.ListPageSize < 0 {
+		return errors.New("list page size must not be negative")
+	}
+
 	// verify local
 	if len(config.LocalPath) == 0 {
 		return errors.New("local path is required")
@@ -207,3 +491,307 @@ func verifyConfig(config *Config) error {
 
 	return nil
 }
+
+func verifySynologyAddress(name string, address *Address) error {
+	if address == nil {
+		return fmt.Errorf("%s config is required", name)
+	}
+	// verify ip address
+	if len(address.IP) == 0 {
+		return fmt.Errorf("%s ip address is required", name)
+	}
+	// verify port number
+	if address.Port == 0 {
+		return fmt.Errorf("%s port is required", name)
+	}
+	if _, err := net.LookupPort("tcp", strconv.Itoa(address.Port)); err != nil {
+		return fmt.Errorf("invalid %s port number", name)
+	}
+	// verify username and password
+	if len(address.Username) == 0 {
+		return fmt.Errorf("%s username is required", name)
+	}
+	if len(address.Password) == 0 {
+		return fmt.Errorf("%s password is required", name)
+	}
+	// verify scheme and tls
+	if err := verifyTLS(name, &address.Scheme, address.TLS); err != nil {
+		return err
+	}
+	// verify two-factor authentication
+	if err := verifyOTP(name, address); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func verifyTLS(name string, scheme *string, tls *TLS) error {
+	switch *scheme {
+	case "":
+		*scheme = "http"
+		log.Printf("%s scheme is not set, credentials are sent in plaintext over http", name)
+	case "http":
+		log.Printf("%s scheme is http, credentials are sent in plaintext", name)
+	case "https":
+	default:
+		return fmt.Errorf("invalid %s scheme: %s", name, *scheme)
+	}
+
+	if tls == nil {
+		return nil
+	}
+	if *scheme != "https" {
+		return fmt.Errorf("%s tls option requires https scheme", name)
+	}
+	if tls.Insecure && (len(tls.Fingerprint) != 0 || len(tls.CAFile) != 0) {
+		return fmt.Errorf("%s tls insecure option can not be used with fingerprint or ca_file", name)
+	}
+	if len(tls.Fingerprint) != 0 {
+		if _, err := protocol.ParseFingerprint(tls.Fingerprint); err != nil {
+			return err
+		}
+	}
+	if len(tls.CAFile) != 0 && !protocol.FileExists(tls.CAFile) {
+		return fmt.Errorf("%s tls ca file %s not found", name, tls.CAFile)
+	}
+
+	return nil
+}
+
+func verifyOTP(name string, address *Address) error {
+	if address.OTP == nil {
+		return nil
+	}
+
+	if len(address.OTP.Secret) != 0 {
+		if _, err := protocol.GenerateTOTP(address.OTP.Secret, time.Now()); err != nil {
+			return errors.Wrapf(err, "invalid %s otp secret", name)
+		}
+	}
+	if len(address.OTP.Secret) == 0 && len(address.OTP.DeviceTokenFile) == 0 {
+		return fmt.Errorf("%s otp secret or device token file is required", name)
+	}
+	if len(address.OTP.DeviceName) == 0 {
+		address.OTP.DeviceName = programName
+	}
+
+	return nil
+}
+
+func verifySourcePaths(address *Address) error {
+	// 하나의 path 만 설정한 경우
+	if len(address.Paths) == 0 {
+		address.Paths = []*SourcePath{{Path: address.Path}}
+	} else if len(address.Path) != 0 {
+		return errors.New("synology path and paths can not be used together")
+	}
+
+	for _, source := range address.Paths {
+		if len(source.Path) == 0 {
+			return errors.New("synology source path is required")
+		}
+		if err := verifySourcePath(source); err != nil {
+			return err
+		}
+	}
+
+	// 겹치는 원본 경로는 같은 파일을 두 번 받게 됨
+	for i, source := range address.Paths {
+		for _, other := range address.Paths[i+1:] {
+			if isSubPath(source.Path, other.Path) || isSubPath(other.Path, source.Path) {
+				return fmt.Errorf("synology source paths %s and %s must not overlap", source.Path, other.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+func verifySourcePath(source *SourcePath) error {
+	// 업로드 경로를 설정하지 않으면 원본 경로를 사용
+	if len(source.Dest) == 0 {
+		source.Dest = source.Path
+	}
+	for _, pattern := range append(append([]string{}, source.Include...), source.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid %s source path pattern %s: %v", source.Path, pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func verifySynologyShare(config *Config) error {
+	share := config.SynologyShare
+	if share == nil {
+		return errors.New("synology_share config is required")
+	}
+	// verify sharing link
+	if len(share.URL) == 0 {
+		return errors.New("synology_share url is required")
+	}
+	sharingURL, sharingID, err := protocol.ParseSharingURL(share.URL)
+	if err != nil {
+		return err
+	}
+	// verify scheme and tls
+	scheme := sharingURL.Scheme
+	if err := verifyTLS("synology_share", &scheme, share.TLS); err != nil {
+		return err
+	}
+	// verify local path
+	if len(share.Path) == 0 {
+		share.Path = "/" + sharingID
+	}
+	if err := verifySourcePath(&share.SourcePath); err != nil {
+		return err
+	}
+
+	return verifyFileStationOnly("synology_share", config)
+}
+
+func verifySynologyPhotos(config *Config) error {
+	photos := config.SynologyPhotos
+	if photos == nil {
+		return errors.New("synology_photos config is required")
+	}
+	// verify spaces
+	if len(photos.Spaces) == 0 {
+		photos.Spaces = []string{string(protocol.PersonalSpace), string(protocol.SharedSpace)}
+	}
+	for _, space := range photos.Spaces {
+		switch protocol.PhotoSpace(space) {
+		case protocol.PersonalSpace, protocol.SharedSpace:
+		default:
+			return fmt.Errorf("invalid synology_photos space: %s", space)
+		}
+	}
+	// verify local path
+	if len(photos.Path) == 0 {
+		photos.Path = "/photos"
+	}
+	if err := verifySourcePath(&photos.SourcePath); err != nil {
+		return err
+	}
+
+	return verifyFileStationOnly("synology_photos", config)
+}
+
+// verifySSHAuth 는 ssh 인증 방법과 각 방법에 필요한 설정을 확인
+func verifySSHAuth(address *Address) error {
+	// 인증 방법을 설정하지 않으면 비밀번호만 사용
+	if address.Auth == nil {
+		if len(address.Password) == 0 {
+			return errors.New("ssh password is required")
+		}
+		return nil
+	}
+
+	auth := address.Auth
+	if len(auth.Methods) == 0 {
+		// 설정한 값으로 개인 키, 비밀번호 순서로 시도
+		if len(auth.KeyFile) != 0 {
+			auth.Methods = append(auth.Methods, protocol.SSHAuthPublicKey)
+		}
+		if len(address.Password) != 0 {
+			auth.Methods = append(auth.Methods, protocol.SSHAuthPassword)
+		}
+		if len(auth.Methods) == 0 {
+			return errors.New("ssh auth methods are required")
+		}
+	}
+
+	for _, method := range auth.Methods {
+		switch method {
+		case protocol.SSHAuthPublicKey:
+			if len(auth.KeyFile) == 0 {
+				return errors.New("ssh key file is required for publickey auth")
+			}
+			if !protocol.FileExists(auth.KeyFile) {
+				return fmt.Errorf("ssh key file %s not found", auth.KeyFile)
+			}
+		case protocol.SSHAuthAgent:
+			if len(auth.AgentSocket) == 0 && len(os.Getenv("SSH_AUTH_SOCK")) == 0 {
+				return errors.New("ssh agent socket is required for agent auth")
+			}
+		case protocol.SSHAuthKeyboardInteractive, protocol.SSHAuthPassword:
+			if len(address.Password) == 0 {
+				return fmt.Errorf("ssh password is required for %s auth", method)
+			}
+		default:
+			return fmt.Errorf("invalid ssh auth method: %s", method)
+		}
+	}
+
+	return nil
+}
+
+// verifySSHHostKey 는 호스트 키 확인 방법을 확인하며 설정하지 않으면 tofu 방식으로 localPath 의 known_hosts 사용
+// Docker 컨테이너의 ~/.ssh 는 재시작하면 사라져 매번 처음 보는 키를 신뢰하게 되므로 데이터 경로에 기록
+func verifySSHHostKey(address *Address, localPath string) error {
+	if address.HostKey == nil {
+		address.HostKey = &HostKey{}
+	}
+	hostKey := address.HostKey
+
+	if len(hostKey.Mode) == 0 {
+		hostKey.Mode = protocol.HostKeyTOFU
+		if len(hostKey.Fingerprint) != 0 {
+			hostKey.Mode = protocol.HostKeyFingerprint
+		}
+	}
+
+	switch hostKey.Mode {
+	case protocol.HostKeyKnownHosts, protocol.HostKeyTOFU:
+		if len(hostKey.KnownHosts) == 0 {
+			if len(localPath) == 0 {
+				return errors.New("local path is required for default known_hosts file")
+			}
+			hostKey.KnownHosts = filepath.Join(localPath, knownHostsFilename)
+		}
+		if hostKey.Mode == protocol.HostKeyKnownHosts && !protocol.FileExists(hostKey.KnownHosts) {
+			return fmt.Errorf("ssh known_hosts file %s not found", hostKey.KnownHosts)
+		}
+	case protocol.HostKeyFingerprint:
+		if len(hostKey.Fingerprint) == 0 {
+			return errors.New("ssh host key fingerprint is required for fingerprint mode")
+		}
+	case protocol.HostKeyInsecure:
+		log.Print("ssh host key verification is disabled")
+	default:
+		return fmt.Errorf("invalid ssh host key mode: %s", hostKey.Mode)
+	}
+
+	return nil
+}
+
+// isSubPath 는 child 가 parent 와 같거나 parent 아래 경로인지 확인
+func isSubPath(child, parent string) bool {
+	child, parent = path.Clean("/"+child), path.Clean("/"+parent)
+	return child == parent || parent == "/" || strings.HasPrefix(child, parent+"/")
+}
+
+// verifyFileStationOnly 는 FileStation 다운로드에서만 지원하는 기능을 사용하는지 확인
+func verifyFileStationOnly(name string, config *Config) error {
+	if config.VerifyChecksum || config.ChangeDetection == "hash" {
+		return fmt.Errorf("%s does not support checksum verification", name)
+	}
+	if config.IncrementalScan {
+		return fmt.Errorf("%s does not support incremental scan", name)
+	}
+
+	return nil
+}
+
+// sourcePaths 는 다운로드 방식별 원본 경로 목록을 반환
+func (config *Config) sourcePaths() []*SourcePath {
+	switch config.DownloadType {
+	case "synology_share":
+		return []*SourcePath{&config.SynologyShare.SourcePath}
+	case "synology_photos":
+		return []*SourcePath{&config.SynologyPhotos.SourcePath}
+	default:
+		return config.Synology.Paths
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestSourcePathMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		file    string
+		want    bool
+	}{
+		{name: "no rule", file: "IMG_0001.JPG", want: true},
+		{name: "include match", include: []string{"*.jpg"}, file: "IMG_0001.jpg", want: true},
+		{name: "include case insensitive", include: []string{"*.jpg"}, file: "IMG_0001.JPG", want: true},
+		{name: "include pattern case insensitive", include: []string{"*.JPG"}, file: "img_0001.jpg", want: true},
+		{name: "include not match", include: []string{"*.jpg"}, file: "IMG_0001.MOV", want: false},
+		{name: "include any", include: []string{"*.jpg", "*.mov"}, file: "IMG_0001.MOV", want: true},
+		{name: "exclude match", exclude: []string{"*.tmp"}, file: "upload.TMP", want: false},
+		{name: "exclude not match", exclude: []string{"*.tmp"}, file: "IMG_0001.JPG", want: true},
+		{name: "exclude over include", include: []string{"*.jpg"}, exclude: []string{"._*"}, file: "._IMG_0001.JPG", want: false},
+		{name: "single character", include: []string{"img_000?.jpg"}, file: "IMG_0001.JPG", want: true},
+		{name: "character class", exclude: []string{"[.~]*"}, file: "~lock.docx", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &SourcePath{Path: "/photo", Include: tt.include, Exclude: tt.exclude}
+			if got := source.Match(tt.file); got != tt.want {
+				t.Errorf("Match(%q) with include %v exclude %v = %v, want %v", tt.file, tt.include, tt.exclude, got, tt.want)
+			}
+		})
+	}
+}
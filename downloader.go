@@ -3,16 +3,55 @@ package main
 import (
 	"context"
 	"github.com/lolgopher/synology-filesync/protocol"
+	"github.com/pkg/errors"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-func downloadSynology(info *protocol.ConnectionInfo) {
+// downloadClient 는 파일 목록 조회와 다운로드를 지원하는 synology client
+type downloadClient interface {
+	GetFileList(ctx context.Context, folderPath string) (*protocol.FileListResponse, error)
+	DownloadFile(ctx context.Context, file *protocol.File, destPath string) (string, int64, error)
+	MD5(ctx context.Context, filePath string) (string, error)
+}
+
+func downloadSynology(ctx context.Context, info *protocol.ConnectionInfo) {
 	// synology client 생성
-	synoClient, err := protocol.NewSynologyClient(info)
+	synoClient, err := protocol.NewSynologyClient(ctx, info)
+	if err != nil {
+		fatalf(ctx, "fail to make synology client: %v", err)
+	}
+	synoClient.PageSize = config.ListPageSize
+	synoClient.MD5Timeout = time.Duration(config.MD5Timeout) * time.Second
+	if config.VerifyChecksum || config.ChangeDetection == "hash" {
+		if _, err := synoClient.API("SYNO.FileStation.MD5"); err != nil {
+			fatalf(ctx, "fail to use checksum verification: %v", err)
+		}
+	}
+	if config.Synology.Filter != nil || config.IncrementalScan {
+		if _, err := synoClient.API("SYNO.FileStation.Search"); err != nil {
+			fatalf(ctx, "fail to use filestation search: %v", err)
+		}
+	}
+	defer func() {
+		if err := synoClient.Logout(ctx); err != nil {
+			log.Printf("fail to logout synology client: %v", err)
+		}
+	}()
+
+	// 마지막 검색 시간 확인
+	scanStart := time.Now()
+	state, err := readScanState()
 	if err != nil {
-		log.Fatalf("fail to make synology client: %v", err)
+		fatalf(ctx, "fail to read scan state: %v", err)
+	}
+	fullScans := make(map[string]bool)
+	for _, source := range config.Synology.Paths {
+		fullScans[source.Path] = state.source(source.Path).needFullScan(scanStart)
 	}
 
 	wg.Add(1)
@@ -21,102 +60,384 @@ func downloadSynology(info *protocol.ConnectionInfo) {
 			wg.Done()
 		}()
 
-		fileListResp, err := searchSynologyRecursive(synoClient, config.Synology.Path, 0)
-		if err != nil {
-			log.Fatalf("fail to search from synology filestation: %v", err)
-		}
-
-		if err := downloadSynologyRecursive(synoClient, fileListResp); err != nil {
-			log.Fatalf("fail to download from synology filestation: %v", err)
+		for _, source := range config.Synology.Paths {
+			downloadSource(ctx, synoClient, source, state.source(source.Path), fullScans[source.Path])
 		}
 	}()
 	wg.Wait()
 
+	// 검색 시간 저장
+	for _, source := range config.Synology.Paths {
+		sourceState := state.source(source.Path)
+		sourceState.LastScan = scanStart.Unix()
+		if fullScans[source.Path] {
+			sourceState.LastFullScan = scanStart.Unix()
+		}
+	}
+	if err := writeScanState(state); err != nil {
+		log.Printf("fail to write scan state: %v", err)
+	}
+
 	log.Print("Done!")
 }
 
-func searchSynologyRecursive(client *protocol.SynologyClient, folderPath string, depth int) (*protocol.FileListResponse, error) {
-	fileListResp, err := client.GetFileList(folderPath)
+func downloadSource(ctx context.Context, client *protocol.SynologyClient, source *SourcePath, state *sourceScanState, fullScan bool) {
+	var fileListResp *protocol.FileListResponse
+	var err error
+	if !fullScan {
+		// 마지막 검색 이후 수정되거나 NAS 에 생성된 파일만 검색
+		scanFrom := time.Unix(state.LastScan, 0).Add(-scanOverlap).Unix()
+		log.Printf("incremental scan %s since %s", source.Path, time.Unix(scanFrom, 0))
+		fileListResp, err = searchSynologyIncremental(ctx, client, source, scanFrom)
+	} else if config.Synology.Filter != nil {
+		// 필터가 있으면 NAS 에서 검색
+		fileListResp, err = searchSynologyFiltered(ctx, client, source, config.Synology.Filter, nil)
+	} else {
+		fileListResp, err = searchSynologyRecursive(ctx, client, source, source.Path, 0)
+	}
+	if err != nil {
+		fatalf(ctx, "fail to search %s from synology filestation: %v", source.Path, err)
+	}
+
+	// 전체 검색 결과에 없는 파일은 삭제된 파일
+	if fullScan && config.IncrementalScan {
+		if err := pruneDeletedFiles(source.Path, fileListResp); err != nil {
+			fatalf(ctx, "fail to prune deleted files: %v", err)
+		}
+	}
+
+	if err := downloadSynologyRecursive(ctx, client, fileListResp); err != nil {
+		fatalf(ctx, "fail to download %s from synology filestation: %v", source.Path, err)
+	}
+}
+
+func searchSynologyRecursive(ctx context.Context, client downloadClient, source *SourcePath, folderPath string, depth int) (*protocol.FileListResponse, error) {
+	var fileListResp *protocol.FileListResponse
+	err := retrySynology(ctx, func() error {
+		var err error
+		fileListResp, err = client.GetFileList(ctx, folderPath)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	files := fileListResp.Data.Files[:0]
 	for _, file := range fileListResp.Data.Files {
 		// 폴더이고 휴지통이 아니면 검색
 		if file.IsDir {
 			if file.Name != "#recycle" {
 				if err := os.MkdirAll(filepath.Join(config.LocalPath, file.Path), os.ModePerm); err != nil {
-					log.Fatalf("fail to make download folder: %v", err)
+					fatalf(ctx, "fail to make download folder: %v", err)
 				}
 
-				file.List, err = searchSynologyRecursive(client, file.Path, depth+1)
+				file.List, err = searchSynologyRecursive(ctx, client, source, file.Path, depth+1)
 				if err != nil {
-					return nil, err
+					// 권한 없는 폴더 등 다시 시도해도 실패하는 하위 폴더는 건너뜀
+					var synoErr *protocol.SynologyError
+					if !errors.As(err, &synoErr) || synoErr.Class() != protocol.Permanent {
+						return nil, err
+					}
+					log.Printf("skip %s folder: %v", file.Path, err)
 				}
 			}
 		} else {
-			initFilePath := filepath.Join(config.LocalPath, file.Path)
+			// include/exclude 규칙에 맞지 않는 파일은 제외
+			if !source.Match(file.Name) {
+				continue
+			}
+			if err := initFileMetadata(ctx, client, file); err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, file)
+	}
+	fileListResp.Data.Files = files
 
-			// 메타데이터가 없으면 초기화
-			if !protocol.FileExists(filepath.Join(filepath.Dir(initFilePath), config.YAML.Filename)) {
-				if err := protocol.WriteMetadata(initFilePath, config.YAML.Filename, file.Additional.Size, protocol.Init); err != nil {
-					log.Fatalf("fail to %s write metadata: %v", initFilePath, err)
-				}
-				log.Printf("init %s metadata", initFilePath)
-			} else {
-				// 이미 메타데이터가 존재하는지 확인
-				targetMetadata, err := protocol.ReadMetadata(filepath.Dir(initFilePath), config.YAML.Filename)
-				if err != nil {
-					return nil, err
-				}
+	return fileListResp, nil
+}
 
-				// 메타데이터에 정보가 없거나 파일 크기가 다르면 초기화
-				if metadata, ok := targetMetadata[initFilePath]; !ok || metadata.Size != file.Additional.Size {
-					if err := protocol.WriteMetadata(initFilePath, config.YAML.Filename, file.Additional.Size, protocol.Init); err != nil {
-						log.Fatalf("fail to %s write metadata: %v", initFilePath, err)
-					}
-					log.Printf("init %s metadata", initFilePath)
-
-					// 기존 파일이 존재하면 삭제
-					if protocol.FileExists(initFilePath) {
-						if err := os.Remove(initFilePath); err != nil {
-							log.Fatalf("fail to %s remove file: %v", initFilePath, err)
-						}
-						log.Printf("remove %s file", initFilePath)
-					}
-				} else {
-					log.Printf("%s metedata already exist", initFilePath)
-				}
+// searchSynologyIncremental 은 scanFrom 이후 수정되거나 생성된 파일과 다시 받아야 하는 파일을 하나의 목록으로 반환
+// 예전 수정 시간을 유지한 채 NAS 에 복사된 파일은 생성 시간으로 찾음
+func searchSynologyIncremental(ctx context.Context, client *protocol.SynologyClient, source *SourcePath, scanFrom int64) (*protocol.FileListResponse, error) {
+	fileListResp := &protocol.FileListResponse{Success: true}
+	seen := make(map[string]bool)
+	for _, searchFilter := range []*protocol.SearchFilter{{MtimeFrom: scanFrom}, {CrtimeFrom: scanFrom}} {
+		searchResp, err := searchSynologyFiltered(ctx, client, source, config.Synology.Filter, searchFilter)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range searchResp.Data.Files {
+			if !seen[file.Path] {
+				seen[file.Path] = true
+				fileListResp.Data.Files = append(fileListResp.Data.Files, file)
 			}
 		}
 	}
 
+	// 체크섬이 맞지 않아 초기화된 파일 등은 수정 시간이 바뀌지 않아 검색되지 않으므로 추가
+	pending, err := searchPendingFiles(ctx, client, source, seen)
+	if err != nil {
+		return nil, err
+	}
+	fileListResp.Data.Files = append(fileListResp.Data.Files, pending...)
+	fileListResp.Data.Total = len(fileListResp.Data.Files)
+
 	return fileListResp, nil
 }
 
-func downloadSynologyRecursive(client *protocol.SynologyClient, fileList *protocol.FileListResponse) error {
-	ctx := context.Background()
+// searchPendingFiles 는 검색 결과에 없는 초기화 상태 파일을 NAS 폴더 목록에서 다시 찾아 반환
+// NAS 에서 삭제된 파일은 폴더 목록에 없으므로 건너뜀
+func searchPendingFiles(ctx context.Context, client *protocol.SynologyClient, source *SourcePath, seen map[string]bool) ([]*protocol.File, error) {
+	pendingDirs := make(map[string]map[string]bool)
+	err := filepath.Walk(source.LocalPath(), func(targetPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != config.YAML.Filename {
+			return nil
+		}
+
+		targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+		if err != nil {
+			return err
+		}
+		for filePath, metadata := range targetMetadata {
+			if metadata.Status != string(protocol.Init) {
+				continue
+			}
+			relPath, err := filepath.Rel(config.LocalPath, filePath)
+			if err != nil {
+				return err
+			}
+			remotePath := path.Join("/", filepath.ToSlash(relPath))
+			if seen[remotePath] {
+				continue
+			}
+
+			dir := path.Dir(remotePath)
+			if pendingDirs[dir] == nil {
+				pendingDirs[dir] = make(map[string]bool)
+			}
+			pendingDirs[dir][path.Base(remotePath)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*protocol.File
+	for dir, names := range pendingDirs {
+		var dirResp *protocol.FileListResponse
+		err := retrySynology(ctx, func() error {
+			var err error
+			dirResp, err = client.GetFileList(ctx, dir)
+			return err
+		})
+		if err != nil {
+			// 삭제된 폴더 등 다시 시도해도 실패하는 폴더는 건너뜀
+			var synoErr *protocol.SynologyError
+			if !errors.As(err, &synoErr) || synoErr.Class() != protocol.Permanent {
+				return nil, err
+			}
+			log.Printf("skip pending files in %s folder: %v", dir, err)
+			continue
+		}
+
+		for _, file := range dirResp.Data.Files {
+			if file.IsDir || !names[file.Name] || !source.Match(file.Name) {
+				continue
+			}
+			if err := initFileMetadata(ctx, client, file); err != nil {
+				return nil, err
+			}
+			log.Printf("%s is pending download, add to incremental scan", file.Path)
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// searchSynologyFiltered 는 FileStation 검색으로 필터와 searchFilter 의 시간 조건에 맞는 파일을 찾아 하나의 목록으로 반환
+func searchSynologyFiltered(ctx context.Context, client *protocol.SynologyClient, source *SourcePath, filter *Filter, searchFilter *protocol.SearchFilter) (*protocol.FileListResponse, error) {
+	if searchFilter == nil {
+		searchFilter = &protocol.SearchFilter{}
+	}
+	if filter != nil {
+		searchFilter.Pattern = filter.Pattern
+		searchFilter.Extension = filter.Extension
+		searchFilter.SizeFrom = filter.SizeFrom
+		searchFilter.SizeTo = filter.SizeTo
+	}
+
+	fileListResp := &protocol.FileListResponse{Success: true}
+	err := retrySynology(ctx, func() error {
+		fileListResp.Data.Files = nil
+		return client.Search(ctx, source.Path, searchFilter, func(file *protocol.File) error {
+			// 휴지통 파일과 include/exclude 규칙에 맞지 않는 파일은 제외
+			if strings.Contains(file.Path, "/#recycle/") || !source.Match(file.Name) {
+				return nil
+			}
+
+			if err := os.MkdirAll(filepath.Join(config.LocalPath, filepath.Dir(file.Path)), os.ModePerm); err != nil {
+				fatalf(ctx, "fail to make download folder: %v", err)
+			}
+			if err := initFileMetadata(ctx, client, file); err != nil {
+				return err
+			}
+
+			fileListResp.Data.Files = append(fileListResp.Data.Files, file)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	fileListResp.Data.Total = len(fileListResp.Data.Files)
+
+	return fileListResp, nil
+}
+
+func initFileMetadata(ctx context.Context, client downloadClient, file *protocol.File) error {
+	initFilePath := filepath.Join(config.LocalPath, file.Path)
+
+	// 메타데이터가 없으면 초기화
+	if !protocol.FileExists(filepath.Join(filepath.Dir(initFilePath), config.YAML.Filename)) {
+		if err := writeInitMetadata(initFilePath, file, false); err != nil {
+			fatalf(ctx, "fail to %s write metadata: %v", initFilePath, err)
+		}
+		log.Printf("init %s metadata", initFilePath)
+		return nil
+	}
+
+	// 이미 메타데이터가 존재하는지 확인
+	targetMetadata, err := protocol.ReadMetadata(filepath.Dir(initFilePath), config.YAML.Filename)
+	if err != nil {
+		return err
+	}
+
+	// 메타데이터에 정보가 없거나 파일이 변경되었으면 초기화
+	metadata, ok := targetMetadata[initFilePath]
+	changed := !ok
+	if ok {
+		changed, err = isFileChanged(ctx, client, file, metadata)
+		if err != nil {
+			return err
+		}
+	}
+	if !changed {
+		// 이전 버전 메타데이터에는 수정 시간이 없으므로 채워넣음
+		if metadata.ModTime != file.Additional.Time.Mtime {
+			if err := protocol.UpdateMetadata(initFilePath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+				metadata.ModTime = file.Additional.Time.Mtime
+			}); err != nil {
+				fatalf(ctx, "fail to %s write metadata: %v", initFilePath, err)
+			}
+		}
+		log.Printf("%s metedata already exist", initFilePath)
+		return nil
+	}
+
+	// 이미 전송한 파일이 바뀌었으면 원격지에 남아 있는 이전 파일을 덮어써야 함
+	replace := ok && (protocol.FileTransferStatus(metadata.Status) == protocol.Sent || metadata.Replace)
+	if err := writeInitMetadata(initFilePath, file, replace); err != nil {
+		fatalf(ctx, "fail to %s write metadata: %v", initFilePath, err)
+	}
+	log.Printf("init %s metadata", initFilePath)
+
+	// 기존 파일과 이어받던 파일이 존재하면 삭제
+	for _, removePath := range []string{initFilePath, initFilePath + ".download"} {
+		if protocol.FileExists(removePath) {
+			if err := os.Remove(removePath); err != nil {
+				fatalf(ctx, "fail to %s remove file: %v", removePath, err)
+			}
+			log.Printf("remove %s file", removePath)
+		}
+	}
+
+	return nil
+}
+
+func writeInitMetadata(filePath string, file *protocol.File, replace bool) error {
+	return protocol.UpdateMetadata(filePath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+		*metadata = protocol.FileMetadata{
+			Size:    file.Additional.Size,
+			ModTime: file.Additional.Time.Mtime,
+			Status:  string(protocol.Init),
+			Replace: replace,
+		}
+	})
+}
+
+// isFileChanged 는 change_detection 설정에 따라 원본 파일이 변경되었는지 확인
+func isFileChanged(ctx context.Context, client downloadClient, file *protocol.File, metadata protocol.FileMetadata) (bool, error) {
+	if metadata.Size != file.Additional.Size {
+		return true, nil
+	}
+	if config.ChangeDetection == "size" {
+		return false, nil
+	}
+
+	// 수정 시간이 기록되지 않은 이전 메타데이터는 크기만 비교
+	if metadata.ModTime == 0 || metadata.ModTime == file.Additional.Time.Mtime {
+		return false, nil
+	}
+	if config.ChangeDetection != "hash" {
+		return true, nil
+	}
+
+	// 수정 시간만 바뀌고 내용은 같을 수 있으므로 해시 비교
+	localHash := metadata.Hash
+	if len(localHash) == 0 {
+		localPath := filepath.Join(config.LocalPath, file.Path)
+		if !protocol.FileExists(localPath) {
+			return true, nil
+		}
+
+		var err error
+		localHash, err = protocol.FileMD5(localPath)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var remoteHash string
+	err := retrySynology(ctx, func() error {
+		var err error
+		remoteHash, err = client.MD5(ctx, file.Path)
+		return err
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "fail to get %s md5", file.Path)
+	}
+	return !strings.EqualFold(remoteHash, localHash), nil
+}
+
+func downloadSynologyRecursive(ctx context.Context, client downloadClient, fileList *protocol.FileListResponse) error {
+	// 작은 파일이 많으면 zip 으로 묶어서 다운로드
+	batched, err := downloadBatches(ctx, client, fileList)
+	if err != nil {
+		return err
+	}
 
 	for _, file := range fileList.Data.Files {
 		// 폴더이고 휴지통이 아니면 검색
 		if file.IsDir {
-			if file.Name != "#recycle" {
-				if err := downloadSynologyRecursive(client, file.List); err != nil {
+			if file.Name != "#recycle" && file.List != nil {
+				if err := downloadSynologyRecursive(ctx, client, file.List); err != nil {
 					return err
 				}
 			}
-		} else {
+		} else if !batched[file] {
 			// 파일이면 다운로드
-			for {
-				if err := sem.Acquire(ctx, 1); err != nil {
-					log.Printf("fail to acquire semaphore: %v", err)
-					continue
-				} else {
-					break
-				}
+			// 취소되면 더 이상 다운로드하지 않음
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return errors.Wrap(err, "fail to acquire semaphore")
 			}
 
-			filePath := file.Path
+			targetFile := file
 
 			wg.Add(1)
 			go func() {
@@ -125,31 +446,119 @@ func downloadSynologyRecursive(client *protocol.SynologyClient, fileList *protoc
 					wg.Done()
 				}()
 
-				targetPath := filepath.Join(config.LocalPath, filePath)
+				downloadSynologyFile(ctx, client, targetFile)
+			}()
+		}
+	}
 
-				// 초기화 상태인지 확인
-				targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
-				if err != nil {
-					log.Fatal(err)
-				}
+	return nil
+}
 
-				if metadata, ok := targetMetadata[targetPath]; ok && metadata.Status != string(protocol.Init) {
-					log.Printf("%s has already been download", targetPath)
-					return
-				}
+// downloadSynologyFile 은 초기화 상태인 파일을 다운로드
+func downloadSynologyFile(ctx context.Context, client downloadClient, file *protocol.File) {
+	filePath := file.Path
+	targetPath := filepath.Join(config.LocalPath, filePath)
 
-				downloadFilePath, _, err := client.DownloadFile(filePath, targetPath)
-				if err != nil {
-					log.Fatalf("fail to %s download file: %v", filePath, err)
-				}
+	// 초기화 상태인지 확인
+	targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+	if err != nil {
+		fatalf(ctx, "%v", err)
+	}
 
-				if err := protocol.WriteMetadata(downloadFilePath, config.YAML.Filename, 0, protocol.NotSent); err != nil {
-					log.Fatalf("fail to %s write metadata: %v", downloadFilePath, err)
-				}
-				log.Printf("%s success download", targetPath)
-			}()
+	if metadata, ok := targetMetadata[targetPath]; ok && metadata.Status != string(protocol.Init) {
+		log.Printf("%s has already been download", targetPath)
+		return
+	}
+
+	var downloadFilePath string
+	err = retrySynology(ctx, func() error {
+		var err error
+		downloadFilePath, _, err = client.DownloadFile(ctx, file, targetPath)
+		return err
+	})
+	if err != nil {
+		fatalf(ctx, "fail to %s download file: %v", filePath, err)
+	}
+
+	finishDownload(ctx, client, file, downloadFilePath)
+}
+
+// finishDownload 는 다운로드 받은 파일의 시간과 체크섬을 확인하고 전송 대기 상태로 기록
+func finishDownload(ctx context.Context, client downloadClient, file *protocol.File, downloadFilePath string) {
+	// 원본 파일 시간 적용
+	if config.PreserveTime {
+		fileTime := file.Additional.Time
+		if err := os.Chtimes(downloadFilePath, fileTime.AccessTime(), fileTime.ModTime()); err != nil {
+			log.Printf("fail to change %s file times: %v", downloadFilePath, err)
 		}
 	}
 
-	return nil
+	// 체크섬 확인
+	var hash string
+	if config.VerifyChecksum {
+		var match bool
+		var err error
+		hash, match, err = verifyChecksum(ctx, client, file.Path, downloadFilePath)
+		if err != nil {
+			// 확인하지 못한 파일은 전송하지 않고 초기화 상태로 두어 다음 주기에 다시 확인
+			log.Printf("fail to verify %s checksum, verify again at next cycle: %v", downloadFilePath, err)
+			return
+		} else if !match {
+			// 다음 주기에 다시 다운로드
+			log.Printf("%s checksum mismatch, download again at next cycle", downloadFilePath)
+			if err := os.Remove(downloadFilePath); err != nil {
+				fatalf(ctx, "fail to %s remove file: %v", downloadFilePath, err)
+			}
+			if err := protocol.WriteMetadata(downloadFilePath, config.YAML.Filename, file.Additional.Size, protocol.Init); err != nil {
+				fatalf(ctx, "fail to %s write metadata: %v", downloadFilePath, err)
+			}
+			return
+		}
+	}
+
+	if err := protocol.UpdateMetadata(downloadFilePath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+		metadata.Status = string(protocol.NotSent)
+		if len(hash) != 0 {
+			metadata.Hash = hash
+		}
+	}); err != nil {
+		fatalf(ctx, "fail to %s write metadata: %v", downloadFilePath, err)
+	}
+	log.Printf("%s success download", downloadFilePath)
+}
+
+// retrySynology 는 재시도 가능한 에러가 발생하면 요청을 다시 시도
+func retrySynology(ctx context.Context, request func() error) error {
+	for i := 1; ; i++ {
+		err := request()
+		if err == nil || !protocol.IsRetryable(ctx, err) || i >= config.DownloadRetryCount {
+			return err
+		}
+
+		log.Printf("%v", err)
+		log.Printf("retrying...")
+		if err := protocol.SleepContext(ctx, time.Duration(config.DownloadRetryDelay)*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// verifyChecksum 은 원격 파일과 다운로드 받은 파일의 MD5 해시를 비교
+func verifyChecksum(ctx context.Context, client downloadClient, remotePath, localPath string) (string, bool, error) {
+	localHash, err := protocol.FileMD5(localPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	var remoteHash string
+	err = retrySynology(ctx, func() error {
+		var err error
+		remoteHash, err = client.MD5(ctx, remotePath)
+		return err
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return localHash, strings.EqualFold(localHash, remoteHash), nil
 }
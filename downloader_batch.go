@@ -0,0 +1,226 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"github.com/pkg/errors"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// batchDownloadClient 는 여러 파일을 zip 으로 묶어서 다운로드할 수 있는 client
+type batchDownloadClient interface {
+	DownloadZip(ctx context.Context, paths []string, size int64, destPath string) (int64, error)
+}
+
+// downloadBatches 는 폴더의 작은 파일들을 zip 으로 묶어서 다운로드하고 묶은 파일을 반환
+func downloadBatches(ctx context.Context, client downloadClient, fileList *protocol.FileListResponse) (map[*protocol.File]bool, error) {
+	batched := make(map[*protocol.File]bool)
+	if config.BatchDownload == nil {
+		return batched, nil
+	}
+	batchClient, ok := client.(batchDownloadClient)
+	if !ok {
+		return batched, nil
+	}
+
+	batches, err := planBatches(fileList)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		// 취소되면 더 이상 다운로드하지 않음
+		if err := sem.Acquire(ctx, 1); err != nil {
+			return nil, errors.Wrap(err, "fail to acquire semaphore")
+		}
+		for _, file := range batch {
+			batched[file] = true
+		}
+
+		targetBatch := batch
+		wg.Add(1)
+		go func() {
+			defer func() {
+				sem.Release(1)
+				wg.Done()
+			}()
+
+			downloadBatch(ctx, client, batchClient, targetBatch)
+		}()
+	}
+
+	return batched, nil
+}
+
+// planBatches 는 폴더별로 아직 다운로드하지 않은 작은 파일이 min_files 개 이상이면 max_size 단위로 묶음
+// 검색 결과는 여러 폴더의 파일이 섞여 있으므로 zip 은 폴더마다 따로 받음
+func planBatches(fileList *protocol.FileListResponse) ([][]*protocol.File, error) {
+	var folders []string
+	candidates := make(map[string][]*protocol.File)
+	folderMetadata := make(map[string]map[string]protocol.FileMetadata)
+	for _, file := range fileList.Data.Files {
+		if file.IsDir || file.Additional.Size == 0 || file.Additional.Size > config.BatchDownload.MaxFileSize {
+			continue
+		}
+
+		folder := path.Dir(file.Path)
+		targetPath := filepath.Join(config.LocalPath, file.Path)
+		metadata, ok := folderMetadata[folder]
+		if !ok {
+			var err error
+			metadata, err = protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+			if err != nil {
+				return nil, err
+			}
+			folderMetadata[folder] = metadata
+			folders = append(folders, folder)
+		}
+		if fileMetadata, ok := metadata[targetPath]; ok && fileMetadata.Status == string(protocol.Init) {
+			candidates[folder] = append(candidates[folder], file)
+		}
+	}
+
+	var batches [][]*protocol.File
+	for _, folder := range folders {
+		if len(candidates[folder]) < config.BatchDownload.MinFiles {
+			continue
+		}
+
+		var batch []*protocol.File
+		var batchSize uint64
+		for _, file := range candidates[folder] {
+			if len(batch) != 0 && batchSize+file.Additional.Size > config.BatchDownload.MaxSize {
+				batches = append(batches, batch)
+				batch, batchSize = nil, 0
+			}
+			batch = append(batch, file)
+			batchSize += file.Additional.Size
+		}
+		if len(batch) != 0 {
+			batches = append(batches, batch)
+		}
+	}
+
+	return batches, nil
+}
+
+// downloadBatch 는 파일들을 zip 으로 받아 각 파일 위치에 풀고 zip 에서 찾지 못한 파일은 하나씩 다운로드
+func downloadBatch(ctx context.Context, client downloadClient, batchClient batchDownloadClient, batch []*protocol.File) {
+	pending := make(map[string]*protocol.File)
+	paths := make([]string, 0, len(batch))
+	var size int64
+	for _, file := range batch {
+		pending[path.Base(file.Path)] = file
+		paths = append(paths, file.Path)
+		size += int64(file.Additional.Size)
+	}
+
+	if err := downloadZip(ctx, batchClient, paths, size, pending, func(file *protocol.File, downloadFilePath string) {
+		finishDownload(ctx, client, file, downloadFilePath)
+	}); err != nil {
+		log.Printf("fail to download %d files as zip, download one by one: %v", len(batch), err)
+	}
+
+	for _, file := range batch {
+		if _, ok := pending[path.Base(file.Path)]; ok {
+			downloadSynologyFile(ctx, client, file)
+		}
+	}
+}
+
+// downloadZip 은 zip 을 받아 pending 에 있는 파일을 풀고 풀어낸 파일은 pending 에서 제거
+func downloadZip(ctx context.Context, client batchDownloadClient, paths []string, size int64, pending map[string]*protocol.File, done func(file *protocol.File, downloadFilePath string)) error {
+	// 업로드 대상에서 제외되도록 .download 로 끝나는 임시 파일 사용
+	dir := filepath.Join(config.LocalPath, path.Dir(paths[0]))
+	zipFile, err := os.CreateTemp(dir, ".batch-*.zip.download")
+	if err != nil {
+		return errors.Wrap(err, "fail to create zip file")
+	}
+	zipPath := zipFile.Name()
+	if err := zipFile.Close(); err != nil {
+		log.Printf("fail to close %s file: %v", zipPath, err)
+	}
+	defer func() {
+		if err := os.Remove(zipPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("fail to remove %s file: %v", zipPath, err)
+		}
+	}()
+
+	err = retrySynology(ctx, func() error {
+		_, err := client.DownloadZip(ctx, paths, size, zipPath)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return errors.Wrapf(err, "fail to open %s zip file", zipPath)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("fail to close %s zip file: %v", zipPath, err)
+		}
+	}()
+
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		file, ok := pending[path.Base(entry.Name)]
+		if !ok {
+			continue
+		}
+
+		targetPath := filepath.Join(config.LocalPath, file.Path)
+		if err := extractZipFile(entry, targetPath, file.Additional.Size); err != nil {
+			log.Printf("fail to extract %s from zip: %v", targetPath, err)
+			continue
+		}
+		delete(pending, path.Base(entry.Name))
+		done(file, targetPath)
+	}
+
+	return nil
+}
+
+// extractZipFile 은 zip 항목을 destPath 에 풀고 크기가 원본과 같은지 확인
+func extractZipFile(entry *zip.File, destPath string, size uint64) error {
+	tempPath := destPath + ".download"
+
+	in, err := entry.Open()
+	if err != nil {
+		return errors.Wrapf(err, "fail to open %s zip entry", entry.Name)
+	}
+	defer func() {
+		if err := in.Close(); err != nil {
+			log.Printf("fail to close %s zip entry: %v", entry.Name, err)
+		}
+	}()
+
+	out, err := os.Create(tempPath)
+	if err != nil {
+		return errors.Wrapf(err, "fail to create %s file", tempPath)
+	}
+	written, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err == nil && uint64(written) != size {
+		err = fmt.Errorf("size mismatch (expected: %d, actual: %d)", size, written)
+	}
+	if err != nil {
+		if err := os.Remove(tempPath); err != nil {
+			log.Printf("fail to remove %s file: %v", tempPath, err)
+		}
+		return errors.Wrapf(err, "fail to write %s file", tempPath)
+	}
+
+	return os.Rename(tempPath, destPath)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"github.com/lolgopher/synology-filesync/protocol"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanBatches(t *testing.T) {
+	type testFile struct {
+		name   string
+		size   uint64
+		isDir  bool
+		status protocol.FileTransferStatus // 비어 있으면 메타데이터에 없음
+	}
+
+	tests := []struct {
+		name  string
+		files []testFile
+		want  [][]string
+	}{
+		{
+			name: "fewer than min files",
+			files: []testFile{
+				{name: "a.jpg", size: 10, status: protocol.Init},
+				{name: "b.jpg", size: 10, status: protocol.Init},
+			},
+		},
+		{
+			name: "one batch",
+			files: []testFile{
+				{name: "a.jpg", size: 10, status: protocol.Init},
+				{name: "b.jpg", size: 10, status: protocol.Init},
+				{name: "c.jpg", size: 10, status: protocol.Init},
+			},
+			want: [][]string{{"a.jpg", "b.jpg", "c.jpg"}},
+		},
+		{
+			name: "split by max size",
+			files: []testFile{
+				{name: "a.jpg", size: 40, status: protocol.Init},
+				{name: "b.jpg", size: 40, status: protocol.Init},
+				{name: "c.jpg", size: 40, status: protocol.Init},
+				{name: "d.jpg", size: 20, status: protocol.Init},
+			},
+			want: [][]string{{"a.jpg", "b.jpg"}, {"c.jpg", "d.jpg"}},
+		},
+		{
+			name: "skip large, empty, folder and not init files",
+			files: []testFile{
+				{name: "large.mov", size: 60, status: protocol.Init},
+				{name: "empty.txt", size: 0, status: protocol.Init},
+				{name: "folder", isDir: true},
+				{name: "sent.jpg", size: 10, status: protocol.NotSent},
+				{name: "unknown.jpg", size: 10},
+				{name: "a.jpg", size: 10, status: protocol.Init},
+				{name: "b.jpg", size: 10, status: protocol.Init},
+				{name: "c.jpg", size: 10, status: protocol.Init},
+			},
+			want: [][]string{{"a.jpg", "b.jpg", "c.jpg"}},
+		},
+		{
+			name: "group by folder",
+			files: []testFile{
+				{name: "2023/a.jpg", size: 10, status: protocol.Init},
+				{name: "2024/a.jpg", size: 10, status: protocol.Init},
+				{name: "2023/b.jpg", size: 10, status: protocol.Init},
+				{name: "2024/b.jpg", size: 10, status: protocol.Init},
+				{name: "2023/c.jpg", size: 10, status: protocol.Init},
+				{name: "2024/c.jpg", size: 10, status: protocol.Init},
+			},
+			want: [][]string{{"2023/a.jpg", "2023/b.jpg", "2023/c.jpg"}, {"2024/a.jpg", "2024/b.jpg", "2024/c.jpg"}},
+		},
+		{
+			name: "min files per folder",
+			files: []testFile{
+				{name: "2023/a.jpg", size: 10, status: protocol.Init},
+				{name: "2023/b.jpg", size: 10, status: protocol.Init},
+				{name: "2024/a.jpg", size: 10, status: protocol.Init},
+				{name: "2024/b.jpg", size: 10, status: protocol.Init},
+				{name: "2024/c.jpg", size: 10, status: protocol.Init},
+			},
+			want: [][]string{{"2024/a.jpg", "2024/b.jpg", "2024/c.jpg"}},
+		},
+		{
+			name: "min files counted after skip",
+			files: []testFile{
+				{name: "large.mov", size: 60, status: protocol.Init},
+				{name: "sent.jpg", size: 10, status: protocol.NotSent},
+				{name: "a.jpg", size: 10, status: protocol.Init},
+				{name: "b.jpg", size: 10, status: protocol.Init},
+			},
+		},
+	}
+
+	oldConfig := config
+	defer func() {
+		config = oldConfig
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = &Config{
+				LocalPath:     t.TempDir(),
+				YAML:          &FileDB{Filename: "metadata.yaml"},
+				BatchDownload: &BatchDownload{MinFiles: 3, MaxFileSize: 50, MaxSize: 100},
+			}
+
+			fileList := &protocol.FileListResponse{}
+			for _, file := range tt.files {
+				filePath := path.Join("/photo", file.name)
+				fileList.Data.Files = append(fileList.Data.Files, &protocol.File{
+					Name:  file.name,
+					Path:  filePath,
+					IsDir: file.isDir,
+				})
+				fileList.Data.Files[len(fileList.Data.Files)-1].Additional.Size = file.size
+
+				targetPath := filepath.Join(config.LocalPath, filePath)
+				if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+					t.Fatalf("fail to make local folder: %v", err)
+				}
+				if len(file.status) != 0 {
+					if err := protocol.WriteMetadata(targetPath, config.YAML.Filename, file.size, file.status); err != nil {
+						t.Fatalf("fail to write %s metadata: %v", targetPath, err)
+					}
+				}
+			}
+
+			batches, err := planBatches(fileList)
+			if err != nil {
+				t.Fatalf("planBatches() error = %v", err)
+			}
+
+			var got [][]string
+			for _, batch := range batches {
+				var names []string
+				for _, file := range batch {
+					names = append(names, file.Name)
+				}
+				got = append(got, names)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
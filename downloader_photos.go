@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type photoEntry struct {
+	space protocol.PhotoSpace
+	item  *protocol.PhotoItem
+}
+
+// photosClient 는 Synology Photos 앨범을 <path>/<space>/<album>/ 폴더 구조로 조회하고 다운로드
+type photosClient struct {
+	client *protocol.SynologyClient
+	root   string
+	spaces []protocol.PhotoSpace
+	albums map[string]bool
+
+	mu          sync.RWMutex
+	albumPaths  map[string]*protocol.Album // 앨범 폴더 경로별 앨범
+	itemPaths   map[string]*photoEntry     // 파일 경로별 항목
+	itemAlbums  map[string][]string        // 항목별 포함된 앨범 이름
+	itemAlbumID map[string]map[int]bool    // 항목별 이미 기록한 앨범
+}
+
+func newPhotosClient(client *protocol.SynologyClient, photos *Photos) (*photosClient, error) {
+	pc := &photosClient{
+		client:      client,
+		root:        photos.Path,
+		albums:      make(map[string]bool),
+		albumPaths:  make(map[string]*protocol.Album),
+		itemPaths:   make(map[string]*photoEntry),
+		itemAlbums:  make(map[string][]string),
+		itemAlbumID: make(map[string]map[int]bool),
+	}
+	for _, name := range photos.Albums {
+		pc.albums[name] = true
+	}
+
+	// 사용할 수 없는 공간은 건너뜀
+	for _, space := range photos.Spaces {
+		if err := client.PhotoSpaceAvailable(protocol.PhotoSpace(space)); err != nil {
+			log.Printf("skip synology photos %s space: %v", space, err)
+			continue
+		}
+		pc.spaces = append(pc.spaces, protocol.PhotoSpace(space))
+	}
+	if len(pc.spaces) == 0 {
+		return nil, fmt.Errorf("synology photos is not available on this dsm")
+	}
+
+	return pc, nil
+}
+
+func (pc *photosClient) GetFileList(ctx context.Context, folderPath string) (*protocol.FileListResponse, error) {
+	fileListResp := &protocol.FileListResponse{Success: true}
+
+	switch {
+	case folderPath == pc.root:
+		// 공간 폴더
+		for _, space := range pc.spaces {
+			fileListResp.Data.Files = append(fileListResp.Data.Files, &protocol.File{
+				Name:  string(space),
+				Path:  path.Join(pc.root, string(space)),
+				IsDir: true,
+			})
+		}
+	case path.Dir(folderPath) == pc.root:
+		// 앨범 폴더
+		space := protocol.PhotoSpace(path.Base(folderPath))
+		albums, err := pc.client.Albums(ctx, space)
+		if err != nil {
+			return nil, err
+		}
+
+		// 같은 이름의 앨범은 API 순서와 상관없이 모두 앨범 ID 를 붙여 구분
+		var selected []*protocol.Album
+		names := make(map[string]int)
+		for _, album := range albums {
+			if len(pc.albums) != 0 && !pc.albums[album.Name] {
+				continue
+			}
+			selected = append(selected, album)
+			names[albumFolderName(album)]++
+		}
+
+		for _, album := range selected {
+			name := albumFolderName(album)
+			if names[name] > 1 {
+				name = fmt.Sprintf("%s (%d)", name, album.ID)
+			}
+
+			albumPath := path.Join(folderPath, name)
+			pc.mu.Lock()
+			pc.albumPaths[albumPath] = album
+			pc.mu.Unlock()
+
+			fileListResp.Data.Files = append(fileListResp.Data.Files, &protocol.File{
+				Name:  name,
+				Path:  albumPath,
+				IsDir: true,
+			})
+		}
+	default:
+		// 앨범 항목
+		pc.mu.RLock()
+		album, ok := pc.albumPaths[folderPath]
+		pc.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%s is not a synology photos album folder", folderPath)
+		}
+
+		var items []*protocol.PhotoItem
+		names := make(map[string]int)
+		err := pc.client.AlbumItems(ctx, album, func(item *protocol.PhotoItem) error {
+			items = append(items, item)
+			names[item.Filename]++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			// 앨범 안에서 같은 이름의 파일은 API 순서와 상관없이 모두 항목 ID 를 붙여 구분
+			name := item.Filename
+			if names[name] > 1 {
+				ext := path.Ext(name)
+				name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), item.ID, ext)
+			}
+
+			file := &protocol.File{
+				Name: name,
+				Path: path.Join(folderPath, name),
+			}
+			file.Additional.Size = item.Filesize
+			file.Additional.Time.Mtime = item.Time
+
+			pc.addItem(file.Path, album, item)
+			fileListResp.Data.Files = append(fileListResp.Data.Files, file)
+		}
+	}
+	fileListResp.Data.Total = len(fileListResp.Data.Files)
+
+	return fileListResp, nil
+}
+
+// addItem 은 항목의 파일 경로와 포함된 앨범을 기록
+func (pc *photosClient) addItem(filePath string, album *protocol.Album, item *protocol.PhotoItem) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.itemPaths[filePath] = &photoEntry{space: album.Space, item: item}
+
+	key := fmt.Sprintf("%s/%d", album.Space, item.ID)
+	if pc.itemAlbumID[key] == nil {
+		pc.itemAlbumID[key] = make(map[int]bool)
+	}
+	if !pc.itemAlbumID[key][album.ID] {
+		pc.itemAlbumID[key][album.ID] = true
+		pc.itemAlbums[key] = append(pc.itemAlbums[key], album.Name)
+	}
+}
+
+// itemAlbumNames 는 파일에 해당하는 항목이 포함된 앨범 이름을 반환
+func (pc *photosClient) itemAlbumNames(filePath string) []string {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	entry, ok := pc.itemPaths[filePath]
+	if !ok {
+		return nil
+	}
+	names := append([]string{}, pc.itemAlbums[fmt.Sprintf("%s/%d", entry.space, entry.item.ID)]...)
+	sort.Strings(names)
+	return names
+}
+
+func (pc *photosClient) DownloadFile(ctx context.Context, file *protocol.File, destPath string) (string, int64, error) {
+	pc.mu.RLock()
+	entry, ok := pc.itemPaths[file.Path]
+	pc.mu.RUnlock()
+	if !ok {
+		return "", 0, fmt.Errorf("%s is not a synology photos item", file.Path)
+	}
+
+	return pc.client.DownloadPhoto(ctx, entry.space, entry.item, destPath)
+}
+
+// MD5 는 Synology Photos 에서 지원하지 않음
+func (pc *photosClient) MD5(_ context.Context, filePath string) (string, error) {
+	return "", fmt.Errorf("md5 of %s is not available on synology photos", filePath)
+}
+
+// albumFolderName 은 앨범 이름을 폴더 이름으로 사용할 수 있게 변환
+func albumFolderName(album *protocol.Album) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(album.Name))
+	if len(name) == 0 || name == "." || name == ".." {
+		name = fmt.Sprintf("album_%d", album.ID)
+	}
+	return name
+}
+
+func downloadSynologyPhotos(ctx context.Context, info *protocol.ConnectionInfo) {
+	// synology client 생성
+	synoClient, err := protocol.NewSynologyClient(ctx, info)
+	if err != nil {
+		fatalf(ctx, "fail to make synology client: %v", err)
+	}
+	synoClient.PageSize = config.ListPageSize
+	defer func() {
+		if err := synoClient.Logout(ctx); err != nil {
+			log.Printf("fail to logout synology client: %v", err)
+		}
+	}()
+
+	pc, err := newPhotosClient(synoClient, config.SynologyPhotos)
+	if err != nil {
+		fatalf(ctx, "fail to make synology photos client: %v", err)
+	}
+
+	source := &config.SynologyPhotos.SourcePath
+	if err := os.MkdirAll(source.LocalPath(), os.ModePerm); err != nil {
+		fatalf(ctx, "fail to make download folder: %v", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			wg.Done()
+		}()
+
+		fileListResp, err := searchSynologyRecursive(ctx, pc, source, source.Path, 0)
+		if err != nil {
+			fatalf(ctx, "fail to search %s from synology photos: %v", source.Path, err)
+		}
+
+		// 앨범 정보 기록
+		if err := writeAlbumMetadata(pc, fileListResp); err != nil {
+			fatalf(ctx, "fail to write album metadata: %v", err)
+		}
+
+		if err := downloadSynologyRecursive(ctx, pc, fileListResp); err != nil {
+			fatalf(ctx, "fail to download %s from synology photos: %v", source.Path, err)
+		}
+	}()
+	wg.Wait()
+
+	log.Print("Done!")
+}
+
+// writeAlbumMetadata 는 각 파일의 항목이 포함된 앨범 목록을 메타데이터에 기록
+func writeAlbumMetadata(pc *photosClient, fileList *protocol.FileListResponse) error {
+	var folderMetadata map[string]protocol.FileMetadata
+	for _, file := range fileList.Data.Files {
+		if file.IsDir {
+			if file.List != nil {
+				if err := writeAlbumMetadata(pc, file.List); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		targetPath := filepath.Join(config.LocalPath, file.Path)
+		if folderMetadata == nil {
+			var err error
+			folderMetadata, err = protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 바뀐 경우에만 기록
+		albums := pc.itemAlbumNames(file.Path)
+		if reflect.DeepEqual(folderMetadata[targetPath].Albums, albums) {
+			continue
+		}
+		if err := protocol.UpdateMetadata(targetPath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+			metadata.Albums = albums
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
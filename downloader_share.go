@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"log"
+	"os"
+)
+
+func downloadSynologyShare(ctx context.Context, info *protocol.SharingInfo) {
+	// 공유 링크 client 생성
+	shareClient, err := protocol.NewSharingClient(ctx, info)
+	if err != nil {
+		fatalf(ctx, "fail to make synology sharing client: %v", err)
+	}
+	shareClient.PageSize = config.ListPageSize
+	defer func() {
+		if err := shareClient.Logout(ctx); err != nil {
+			log.Printf("fail to logout synology sharing client: %v", err)
+		}
+	}()
+
+	source := &config.SynologyShare.SourcePath
+	if err := os.MkdirAll(source.LocalPath(), os.ModePerm); err != nil {
+		fatalf(ctx, "fail to make download folder: %v", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			wg.Done()
+		}()
+
+		// 공유 폴더는 source.Path 아래에 저장
+		fileListResp, err := searchSynologyRecursive(ctx, shareClient, source, source.Path, 0)
+		if err != nil {
+			fatalf(ctx, "fail to search %s from synology sharing link: %v", info.URL, err)
+		}
+
+		if err := downloadSynologyRecursive(ctx, shareClient, fileListResp); err != nil {
+			fatalf(ctx, "fail to download %s from synology sharing link: %v", info.URL, err)
+		}
+	}()
+	wg.Wait()
+
+	log.Print("Done!")
+}
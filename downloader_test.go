@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitFileMetadataReplace(t *testing.T) {
+	tests := []struct {
+		name        string
+		metadata    *protocol.FileMetadata // nil 이면 메타데이터에 없음
+		mtime       int64
+		wantStatus  protocol.FileTransferStatus
+		wantReplace bool
+	}{
+		{name: "new file", mtime: 200, wantStatus: protocol.Init},
+		{name: "sent and changed", metadata: &protocol.FileMetadata{Size: 10, ModTime: 100, Status: string(protocol.Sent)}, mtime: 200, wantStatus: protocol.Init, wantReplace: true},
+		{name: "sent and not changed", metadata: &protocol.FileMetadata{Size: 10, ModTime: 100, Status: string(protocol.Sent)}, mtime: 100, wantStatus: protocol.Sent},
+		{name: "not sent and changed", metadata: &protocol.FileMetadata{Size: 10, ModTime: 100, Status: string(protocol.NotSent)}, mtime: 200, wantStatus: protocol.Init},
+		{name: "changed again before sent", metadata: &protocol.FileMetadata{Size: 10, ModTime: 100, Status: string(protocol.NotSent), Replace: true}, mtime: 200, wantStatus: protocol.Init, wantReplace: true},
+	}
+
+	oldConfig := config
+	defer func() {
+		config = oldConfig
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = &Config{
+				LocalPath:       t.TempDir(),
+				YAML:            &FileDB{Filename: "metadata.yaml"},
+				ChangeDetection: "mtime",
+			}
+
+			file := &protocol.File{Name: "a.jpg", Path: "/photo/a.jpg"}
+			file.Additional.Size = 10
+			file.Additional.Time.Mtime = tt.mtime
+
+			targetPath := filepath.Join(config.LocalPath, file.Path)
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				t.Fatalf("fail to make local folder: %v", err)
+			}
+			if tt.metadata != nil {
+				if err := protocol.UpdateMetadata(targetPath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+					*metadata = *tt.metadata
+				}); err != nil {
+					t.Fatalf("fail to write %s metadata: %v", targetPath, err)
+				}
+			}
+
+			if err := initFileMetadata(context.Background(), nil, file); err != nil {
+				t.Fatalf("initFileMetadata() error = %v", err)
+			}
+
+			targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+			if err != nil {
+				t.Fatalf("fail to read metadata: %v", err)
+			}
+			got := targetMetadata[targetPath]
+			if protocol.FileTransferStatus(got.Status) != tt.wantStatus {
+				t.Errorf("initFileMetadata() status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if got.Replace != tt.wantReplace {
+				t.Errorf("initFileMetadata() replace = %v, want %v", got.Replace, tt.wantReplace)
+			}
+		})
+	}
+}
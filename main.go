@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/lolgopher/synology-filesync/protocol"
@@ -57,7 +58,9 @@ func main() {
 	}
 	sem = semaphore.NewWeighted(int64(config.DownloadWorker))
 
-	// Interrupt Signal 받기
+	// Interrupt Signal 받으면 진행 중인 요청 취소
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	go func() {
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -65,6 +68,10 @@ func main() {
 		// Ctrl+C
 		<-c
 		log.Print("got terminated signal")
+		cancel()
+
+		// 다시 받으면 바로 종료
+		<-c
 		os.Exit(0)
 	}()
 
@@ -72,24 +79,136 @@ func main() {
 	defer ticker.Stop()
 
 	// 연결 정보 설정
-	synologyInfo := &protocol.ConnectionInfo{
-		IP:       config.Synology.IP,
-		Port:     config.Synology.Port,
-		Username: config.Synology.Username,
-		Password: config.Synology.Password,
+	var synologyInfo *protocol.ConnectionInfo
+	var shareInfo *protocol.SharingInfo
+	switch config.DownloadType {
+	case "synology_share":
+		shareInfo = newSharingInfo(config.SynologyShare)
+	default:
+		synologyInfo = newSynologyInfo(config.Synology)
 	}
-	remoteInfo := &protocol.ConnectionInfo{
-		IP:       config.SSH.IP,
-		Port:     config.SSH.Port,
-		Username: config.SSH.Username,
-		Password: config.SSH.Password,
+	var remoteInfo *protocol.ConnectionInfo
+	switch config.UploadType {
+	case "synology":
+		remoteInfo = newSynologyInfo(config.SynologyUpload)
+	default:
+		remoteInfo = &protocol.ConnectionInfo{
+			IP:       config.SSH.IP,
+			Port:     config.SSH.Port,
+			Username: config.SSH.Username,
+			Password: config.SSH.Password,
+			Transfer: newTransferLimit(),
+		}
+		if config.SSH.HostKey != nil {
+			remoteInfo.HostKey = &protocol.HostKeyInfo{
+				Mode:           config.SSH.HostKey.Mode,
+				KnownHostsFile: config.SSH.HostKey.KnownHosts,
+				Fingerprint:    config.SSH.HostKey.Fingerprint,
+			}
+		}
+		if config.SSH.Auth != nil {
+			remoteInfo.SSHAuth = &protocol.SSHAuthInfo{
+				Methods:       config.SSH.Auth.Methods,
+				KeyFile:       config.SSH.Auth.KeyFile,
+				KeyPassphrase: config.SSH.Auth.KeyPassphrase,
+				AgentSocket:   config.SSH.Auth.AgentSocket,
+			}
+		}
 	}
 
-	for ; true; <-ticker.C {
+	for {
 		// FileStation.List API 호출
-		downloadSynology(synologyInfo)
+		switch config.DownloadType {
+		case "synology_share":
+			downloadSynologyShare(ctx, shareInfo)
+		case "synology_photos":
+			downloadSynologyPhotos(ctx, synologyInfo)
+		default:
+			downloadSynology(ctx, synologyInfo)
+		}
 
 		// 파일 전송
-		uploadRemote(remoteInfo)
+		uploadRemote(ctx, remoteInfo)
+
+		select {
+		case <-ctx.Done():
+			log.Print("terminated")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fatalf 는 에러를 기록하고 종료하며 종료 신호로 요청이 취소된 경우에는 정상 종료
+func fatalf(ctx context.Context, format string, v ...interface{}) {
+	if ctx.Err() != nil {
+		log.Printf(format, v...)
+		log.Print("terminated")
+		os.Exit(0)
+	}
+	log.Fatalf(format, v...)
+}
+
+// newTimeoutInfo 는 설정의 HTTP 제한 시간을 반환
+func newTimeoutInfo() *protocol.TimeoutInfo {
+	return &protocol.TimeoutInfo{
+		Connect:        time.Duration(config.ConnectTimeout) * time.Second,
+		ResponseHeader: time.Duration(config.ResponseHeaderTimeout) * time.Second,
+		Idle:           time.Duration(config.IdleConnTimeout) * time.Second,
+	}
+}
+
+// newTransferLimit 는 설정의 파일 전송 제한 시간을 반환
+// 파일별 최대 전송 시간은 transfer_base_timeout + 파일 크기 / transfer_min_rate
+func newTransferLimit() *protocol.TransferLimit {
+	return &protocol.TransferLimit{
+		StallTimeout: time.Duration(config.StallTimeout) * time.Second,
+		BaseTimeout:  time.Duration(config.TransferBaseTimeout) * time.Second,
+		MinRate:      config.TransferMinRate,
+	}
+}
+
+func newSynologyInfo(address *Address) *protocol.ConnectionInfo {
+	info := &protocol.ConnectionInfo{
+		IP:       address.IP,
+		Port:     address.Port,
+		Username: address.Username,
+		Password: address.Password,
+		Scheme:   address.Scheme,
+		Timeout:  newTimeoutInfo(),
+		Transfer: newTransferLimit(),
+	}
+	if address.TLS != nil {
+		info.TLS = &protocol.TLSInfo{
+			CAFile:      address.TLS.CAFile,
+			Fingerprint: address.TLS.Fingerprint,
+			Insecure:    address.TLS.Insecure,
+		}
+	}
+	if address.OTP != nil {
+		info.OTP = &protocol.OTPInfo{
+			Secret:          address.OTP.Secret,
+			DeviceName:      address.OTP.DeviceName,
+			DeviceTokenFile: address.OTP.DeviceTokenFile,
+		}
+	}
+	return info
+}
+
+func newSharingInfo(share *Share) *protocol.SharingInfo {
+	info := &protocol.SharingInfo{
+		URL:      share.URL,
+		Password: share.Password,
+		Root:     share.Path,
+		Timeout:  newTimeoutInfo(),
+		Transfer: newTransferLimit(),
+	}
+	if share.TLS != nil {
+		info.TLS = &protocol.TLSInfo{
+			CAFile:      share.TLS.CAFile,
+			Fingerprint: share.TLS.Fingerprint,
+			Insecure:    share.TLS.Insecure,
+		}
 	}
+	return info
 }
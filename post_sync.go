@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"github.com/pkg/errors"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// postSyncer 는 전송이 끝난 원본 파일을 이동하거나 삭제
+type postSyncer struct {
+	client *protocol.SynologyClient
+	since  int64 // post sync 를 켠 시간, 이전에 전송된 파일은 처리하지 않음
+}
+
+func newPostSyncer(ctx context.Context, info *protocol.ConnectionInfo, since int64) (*postSyncer, error) {
+	// synology client 생성
+	client, err := protocol.NewSynologyClient(ctx, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make synology client")
+	}
+
+	ps := &postSyncer{client: client, since: since}
+	for _, api := range postSyncAPIs(config.PostSync.Action) {
+		if _, err := client.API(api); err != nil {
+			_ = ps.Close(ctx)
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+func postSyncAPIs(action string) []string {
+	switch action {
+	case "move":
+		return []string{"SYNO.FileStation.CopyMove", "SYNO.FileStation.CreateFolder"}
+	case "delete":
+		return []string{"SYNO.FileStation.Delete"}
+	default:
+		return nil
+	}
+}
+
+// Run 은 전송된 파일의 원본에 post sync 작업을 수행하고 결과를 메타데이터에 기록
+func (ps *postSyncer) Run(ctx context.Context, source *SourcePath, targetPath string, metadata protocol.FileMetadata) error {
+	// 이미 처리된 파일
+	if metadata.PostSync == string(protocol.Moved) || metadata.PostSync == string(protocol.Deleted) {
+		return nil
+	}
+	// post sync 를 켜기 전에 전송된 파일은 한꺼번에 옮겨지거나 삭제되지 않도록 처리하지 않음
+	if metadata.SentAt < ps.since {
+		return nil
+	}
+
+	sourcePath, _ := strings.CutPrefix(targetPath, config.LocalPath)
+	sourcePath = filepath.ToSlash(sourcePath)
+
+	var result protocol.PostSyncStatus
+	var err error
+	switch config.PostSync.Action {
+	case "move":
+		result = protocol.Moved
+		err = ps.move(ctx, source, sourcePath)
+	case "delete":
+		result = protocol.Deleted
+		err = retrySynology(ctx, func() error {
+			return ps.client.Delete(ctx, sourcePath)
+		})
+	default:
+		return nil
+	}
+	if err != nil {
+		log.Printf("fail to %s %s source file: %v", config.PostSync.Action, sourcePath, err)
+		result = protocol.PostSyncFailed
+	} else {
+		log.Printf("%s source file %s", strings.ToLower(string(result)), sourcePath)
+	}
+
+	return protocol.UpdateMetadata(targetPath, config.YAML.Filename, func(metadata *protocol.FileMetadata) {
+		metadata.PostSync = string(result)
+	})
+}
+
+// move 는 원본 파일을 archive 경로 아래 같은 폴더 구조로 이동
+func (ps *postSyncer) move(ctx context.Context, source *SourcePath, sourcePath string) error {
+	relPath, _ := strings.CutPrefix(path.Dir(sourcePath), source.Path)
+	destFolderPath := path.Join(config.PostSync.ArchivePath, relPath)
+
+	return retrySynology(ctx, func() error {
+		if err := ps.client.CreateFolder(ctx, destFolderPath); err != nil {
+			return errors.Wrapf(err, "fail to create %s folder", destFolderPath)
+		}
+		return ps.client.Move(ctx, sourcePath, destFolderPath, config.PostSync.Overwrite)
+	})
+}
+
+func (ps *postSyncer) Close(ctx context.Context) error {
+	return ps.client.Logout(ctx)
+}
@@ -11,8 +11,15 @@ import (
 )
 
 type FileMetadata struct {
-	Size   uint64 `yaml:"size"`
-	Status string `yaml:"status"`
+	Size    uint64 `yaml:"size"`
+	ModTime int64  `yaml:"mtime,omitempty"`
+	Status  string `yaml:"status"`
+	Hash    string `yaml:"hash,omitempty"`
+
+	SentAt   int64    `yaml:"sent_at,omitempty"`
+	Replace  bool     `yaml:"replace,omitempty"` // 전송한 뒤 원본이 바뀌어 원격지 파일을 덮어써야 함
+	PostSync string   `yaml:"post_sync,omitempty"`
+	Albums   []string `yaml:"albums,omitempty"`
 }
 
 type FileTransferStatus string
@@ -24,6 +31,14 @@ const (
 	Failed  = FileTransferStatus("FAILED")
 )
 
+type PostSyncStatus string
+
+const (
+	Moved          = PostSyncStatus("MOVED")
+	Deleted        = PostSyncStatus("DELETED")
+	PostSyncFailed = PostSyncStatus("FAILED")
+)
+
 var mu sync.Mutex
 
 func ReadMetadata(folderPath, filename string) (map[string]FileMetadata, error) {
@@ -51,6 +66,16 @@ func ReadMetadata(folderPath, filename string) (map[string]FileMetadata, error)
 }
 
 func WriteMetadata(filePath, filename string, size uint64, status FileTransferStatus) error {
+	return UpdateMetadata(filePath, filename, func(metadata *FileMetadata) {
+		// 초기화 상태면 기존 정보 삭제(원격지 파일을 덮어써야 하는지는 유지)
+		if status == Init {
+			*metadata = FileMetadata{Size: size, Replace: metadata.Replace}
+		}
+		metadata.Status = string(status)
+	})
+}
+
+func UpdateMetadata(filePath, filename string, update func(metadata *FileMetadata)) error {
 	// 크리티컬 섹션 설정
 	mu.Lock()
 	defer mu.Unlock()
@@ -75,18 +100,45 @@ func WriteMetadata(filePath, filename string, size uint64, status FileTransferSt
 		log.Printf("error to unmarshal write data: %s", string(data))
 		return fmt.Errorf("fail to unmarshal %s metadata file: %v", metadataFilePath, err)
 	}
-	if status != Init {
-		size = metadata[filePath].Size
+	fileMetadata := metadata[filePath]
+	update(&fileMetadata)
+	metadata[filePath] = fileMetadata
+
+	// 메타데이터 파일 쓰기
+	metadataData, err := yaml.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("fail to marshal %s : %s metadata file: %v", filePath, fileMetadata.Status, err)
+	}
+	if err := os.WriteFile(metadataFilePath, metadataData, 0644); err != nil {
+		return fmt.Errorf("fail to write %s file: %v", metadataFilePath, err)
+	}
+
+	return nil
+}
+
+func DeleteMetadata(filePath, filename string) error {
+	// 크리티컬 섹션 설정
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 메타데이터 파일 읽기
+	metadataFilePath := filepath.Join(filepath.Dir(filePath), filename)
+	data, err := os.ReadFile(metadataFilePath)
+	if err != nil {
+		return fmt.Errorf("fail to read %s metadata file: %v", metadataFilePath, err)
 	}
-	metadata[filePath] = FileMetadata{
-		Size:   size,
-		Status: string(status),
+
+	metadata := make(map[string]FileMetadata)
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		log.Printf("error to unmarshal delete data: %s", string(data))
+		return fmt.Errorf("fail to unmarshal %s metadata file: %v", metadataFilePath, err)
 	}
+	delete(metadata, filePath)
 
 	// 메타데이터 파일 쓰기
 	metadataData, err := yaml.Marshal(metadata)
 	if err != nil {
-		return fmt.Errorf("fail to marshal %s : %s metadata file: %v", filePath, status, err)
+		return fmt.Errorf("fail to marshal %s metadata file: %v", metadataFilePath, err)
 	}
 	if err := os.WriteFile(metadataFilePath, metadataData, 0644); err != nil {
 		return fmt.Errorf("fail to write %s file: %v", metadataFilePath, err)
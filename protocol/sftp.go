@@ -6,25 +6,42 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
 
+// sftpResumeMargin 은 동시 쓰기 중 끊겼을 때 완료되지 않았을 수 있는 범위 (최대 동시 요청 64개 * 최대 패킷 32KB)
+const sftpResumeMargin = 64 * 32 * 1024
+
 type SFTPClient struct {
 	ConnInfo *ConnectionInfo
 	Client   *sftp.Client
 }
 
 func NewSFTPClient(info *ConnectionInfo) (*SFTPClient, error) {
+	// SSH 인증 방법 설정
+	auths, cleanup, err := sshAuthMethods(info)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make ssh auth methods")
+	}
+	defer cleanup()
+
+	// 호스트 키 확인 방법 설정
+	checkHostKey, err := hostKeyCallback(info.HostKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make host key callback")
+	}
+
 	// SSH 연결 정보 설정
 	sshConfig := &ssh.ClientConfig{
-		User: info.Username,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(info.Password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		User:            info.Username,
+		Auth:            auths,
+		HostKeyCallback: checkHostKey,
 	}
 
 	// SSH 클라이언트 생성
@@ -35,7 +52,8 @@ func NewSFTPClient(info *ConnectionInfo) (*SFTPClient, error) {
 	}
 
 	// SFTP 클라이언트 생성
-	sftpClient, err := sftp.NewClient(sshClient)
+	// 응답을 기다리지 않고 여러 쓰기 요청을 보내도록 설정
+	sftpClient, err := sftp.NewClient(sshClient, sftp.UseConcurrentWrites(true))
 	if err != nil {
 		return nil, errors.Wrap(err, "fail to create SFTP client")
 	}
@@ -46,19 +64,21 @@ func NewSFTPClient(info *ConnectionInfo) (*SFTPClient, error) {
 	}, nil
 }
 
-func (sc *SFTPClient) SendFile(localFilePath, remoteFilePath string) (int, error) {
+type SendOptions struct {
+	Resume    bool      // 중단된 partial 파일이 있으면 이어서 전송
+	Overwrite bool      // 원격지에 있는 이전 파일을 덮어씀
+	ModTime   time.Time // 비어 있지 않으면 원래 이름으로 변경하기 전에 적용할 수정 시간
+}
+
+// SendFile 은 로컬 파일을 읽으면서 숨김 임시 파일에 전송하고 크기를 확인한 후 원래 이름으로 변경
+// Resume 이면 partial 파일에 전송하고 중단된 partial 파일이 있으면 이어서 전송
+// 전송한 크기를 반환하며 같은 파일이 이미 있으면 0 을 반환
+// Overwrite 이면 원격지 파일을 확인하지 않고 전송을 마친 후 이전 파일을 대체
+func (sc *SFTPClient) SendFile(localFilePath, remoteFilePath string, options SendOptions) (int64, error) {
 	// 원격지에서 해당 파일이 이미 존재하는지 확인
-	remoteFile, err := sc.Client.Stat(remoteFilePath)
-	if err == nil {
-		isSame, err := IsSameFileSize(localFilePath, remoteFile)
-		if err != nil {
-			return 0, fmt.Errorf("fail to check same file %s and %s: %v", localFilePath, remoteFilePath, err)
-		}
-		// 같은 파일인 경우
-		if isSame {
-			return 0, nil
-		} else {
-			return 0, fmt.Errorf("file %s already exist", remoteFilePath)
+	if !options.Overwrite {
+		if exist, err := sc.checkRemoteFile(localFilePath, remoteFilePath); err != nil || exist {
+			return 0, err
 		}
 	}
 
@@ -72,11 +92,11 @@ func (sc *SFTPClient) SendFile(localFilePath, remoteFilePath string) (int, error
 			log.Printf("fail to close %s file: %v", localFilePath, err)
 		}
 	}()
-
-	localFileContent, err := io.ReadAll(localFile)
+	localFileInfo, err := localFile.Stat()
 	if err != nil {
-		return 0, errors.Wrap(err, "fail to read local file")
+		return 0, errors.Wrap(err, "fail to stat local file")
 	}
+	size := localFileInfo.Size()
 
 	// 경로 생성
 	dir := filepath.Dir(remoteFilePath)
@@ -86,25 +106,214 @@ func (sc *SFTPClient) SendFile(localFilePath, remoteFilePath string) (int, error
 		}
 	}
 
+	// 다른 앱이 전송 중인 파일을 읽지 않도록 숨김 파일에 전송
+	tempPath := sftpTempPath(remoteFilePath, ".tmp")
+	infoPath := sftpTempPath(remoteFilePath, ".partial.info")
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	var offset int64
+	if options.Resume {
+		// 이전에 같은 원본을 보내다가 중단된 파일이 있으면 이어서 전송
+		tempPath = sftpTempPath(remoteFilePath, ".partial")
+		source := partialSource(localFileInfo)
+		if offset = sc.resumeOffset(tempPath, infoPath, source, size); offset > 0 {
+			flag = os.O_CREATE | os.O_WRONLY
+		} else if err := sc.writePartialInfo(infoPath, source); err != nil {
+			return 0, err
+		}
+	}
+
 	// 파일 전송
-	newFile, err := sc.Client.OpenFile(remoteFilePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL)
+	newFile, err := sc.Client.OpenFile(tempPath, flag)
 	if err != nil {
-		return 0, errors.Wrap(err, "fail to create remote file")
+		return 0, errors.Wrap(err, "fail to open remote temp file")
 	}
+	// 전송에 실패했을 때만 닫음(이미 닫은 파일을 다시 닫으면 서버가 에러를 반환)
+	closed := false
 	defer func() {
+		if closed {
+			return
+		}
 		if err := newFile.Close(); err != nil {
-			log.Printf("fail to close %s file: %v", remoteFilePath, err)
+			log.Printf("fail to close %s file: %v", tempPath, err)
 		}
 	}()
+	if offset > 0 {
+		if _, err := newFile.Seek(offset, io.SeekStart); err != nil {
+			return 0, errors.Wrap(err, "fail to seek remote temp file")
+		}
+		if _, err := localFile.Seek(offset, io.SeekStart); err != nil {
+			return 0, errors.Wrap(err, "fail to seek local file")
+		}
+		log.Printf("resume %s upload from %d byte", localFilePath, offset)
+	}
 
-	size, err := newFile.Write(localFileContent)
-	if err != nil {
-		return 0, errors.Wrap(err, "fail to write to remote file")
+	// 전송이 멈추면 파일을 닫다가 같이 멈출 수 있으므로 연결을 끊어 중단
+	watcher := watchTransfer(sc.ConnInfo.Transfer, localFilePath, size-offset, func() {
+		if err := sc.Client.Close(); err != nil {
+			log.Printf("fail to close sftp client: %v", err)
+		}
+	})
+	defer watcher.Stop()
+
+	// 크기를 알 수 있어야 동시 쓰기를 사용하므로 LimitedReader 로 전달
+	if _, err := newFile.ReadFrom(&io.LimitedReader{R: watcher.Reader(localFile), N: size - offset}); err != nil {
+		// 이어서 보내지 않으면 다음 시도에서 덮어씀
+		if !options.Resume {
+			sc.removeTempFile(tempPath)
+		}
+		return 0, watcher.Err(errors.Wrap(err, "fail to write to remote temp file"))
+	}
+	watcher.Stop()
+	closed = true
+	if err := newFile.Close(); err != nil {
+		return 0, errors.Wrap(err, "fail to close remote temp file")
+	}
+
+	if err := sc.finishFile(tempPath, remoteFilePath, size, options); err != nil {
+		if !options.Resume {
+			sc.removeTempFile(tempPath)
+		}
+		return 0, err
+	}
+	if options.Resume {
+		sc.removeTempFile(infoPath)
 	}
 
 	return size, nil
 }
 
+// partialSource 는 partial 파일이 어떤 원본을 보내던 것인지 확인할 원본 크기와 수정 시간
+func partialSource(info os.FileInfo) string {
+	return fmt.Sprintf("%d %d", info.Size(), info.ModTime().UnixNano())
+}
+
+// resumeOffset 은 partial 파일에서 이어서 보낼 위치를 반환하며 이어서 보낼 수 없으면 0 을 반환
+// 원본이 바뀌었으면 이전 내용 뒤에 새 내용을 붙이게 되므로 infoPath 에 기록한 원본과 같을 때만 이어서 보냄
+func (sc *SFTPClient) resumeOffset(partialPath, infoPath, source string, size int64) int64 {
+	partialInfo, err := sc.Client.Stat(partialPath)
+	if err != nil {
+		return 0
+	}
+	if recorded, err := sc.readPartialInfo(infoPath); err != nil || recorded != source {
+		log.Printf("partial %s file was sent from a different source, send again", partialPath)
+		return 0
+	}
+	if partialInfo.Size() > size {
+		log.Printf("partial %s file is bigger than source, send again", partialPath)
+		return 0
+	}
+
+	// 동시 쓰기 중 완료되지 않은 요청이 있었을 수 있으므로 그만큼 앞에서부터 다시 전송
+	if offset := partialInfo.Size() - sftpResumeMargin; offset > 0 {
+		return offset
+	}
+	return 0
+}
+
+func (sc *SFTPClient) readPartialInfo(infoPath string) (string, error) {
+	infoFile, err := sc.Client.Open(infoPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := infoFile.Close(); err != nil {
+			log.Printf("fail to close %s file: %v", infoPath, err)
+		}
+	}()
+
+	data, err := io.ReadAll(infoFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (sc *SFTPClient) writePartialInfo(infoPath, source string) error {
+	infoFile, err := sc.Client.OpenFile(infoPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return errors.Wrap(err, "fail to open remote partial info file")
+	}
+	if _, err := infoFile.Write([]byte(source + "\n")); err != nil {
+		_ = infoFile.Close()
+		return errors.Wrap(err, "fail to write remote partial info file")
+	}
+	if err := infoFile.Close(); err != nil {
+		return errors.Wrap(err, "fail to close remote partial info file")
+	}
+	return nil
+}
+
+func (sc *SFTPClient) removeTempFile(tempPath string) {
+	if err := sc.Client.Remove(tempPath); err != nil {
+		log.Printf("fail to remove %s file: %v", tempPath, err)
+	}
+}
+
+// finishFile 은 임시 파일의 크기가 원본과 같은지 확인한 후 원래 이름으로 변경
+// 크기가 원본보다 크면 이어서 보낼 수 없으므로 삭제
+func (sc *SFTPClient) finishFile(tempPath, remoteFilePath string, size int64, options SendOptions) error {
+	// 파일 크기 확인
+	tempInfo, err := sc.Client.Stat(tempPath)
+	if err != nil {
+		return errors.Wrap(err, "fail to stat remote temp file")
+	}
+	if tempInfo.Size() != size {
+		if tempInfo.Size() > size {
+			sc.removeTempFile(tempPath)
+		}
+		return &SizeMismatchError{
+			Path:     remoteFilePath,
+			Expected: uint64(size),
+			Actual:   uint64(tempInfo.Size()),
+		}
+	}
+
+	// 다른 앱이 전송 시간으로 파일을 읽지 않도록 이름을 바꾸기 전에 원본 시간 적용
+	if !options.ModTime.IsZero() {
+		if err := sc.Client.Chtimes(tempPath, options.ModTime, options.ModTime); err != nil {
+			log.Printf("fail to change %s remote file times: %v", remoteFilePath, err)
+		}
+	}
+
+	// posix-rename 확장을 지원하지 않는 서버는 일반 rename 사용
+	if err := sc.Client.PosixRename(tempPath, remoteFilePath); err != nil {
+		// 일반 rename 은 같은 이름의 파일이 있으면 실패하므로 덮어쓸 이전 파일을 먼저 삭제
+		if options.Overwrite {
+			if err := sc.Client.Remove(remoteFilePath); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "fail to remove previous %s file", remoteFilePath)
+			}
+		}
+		if err := sc.Client.Rename(tempPath, remoteFilePath); err != nil {
+			return errors.Wrapf(err, "fail to rename %s to %s", tempPath, remoteFilePath)
+		}
+	}
+
+	return nil
+}
+
+// sftpTempPath 는 전송 중에 사용할 같은 폴더의 숨김 파일 경로를 반환
+func sftpTempPath(remoteFilePath, suffix string) string {
+	return path.Join(path.Dir(remoteFilePath), "."+path.Base(remoteFilePath)+suffix)
+}
+
+// checkRemoteFile 은 원격지에 같은 크기의 파일이 있으면 true 를 반환하고 크기가 다른 파일이 있으면 에러를 반환
+func (sc *SFTPClient) checkRemoteFile(localFilePath, remoteFilePath string) (bool, error) {
+	remoteFile, err := sc.Client.Stat(remoteFilePath)
+	if err != nil {
+		return false, nil
+	}
+
+	isSame, err := IsSameFileSize(localFilePath, remoteFile)
+	if err != nil {
+		return false, fmt.Errorf("fail to check same file %s and %s: %v", localFilePath, remoteFilePath, err)
+	}
+	// 같은 파일인 경우
+	if !isSame {
+		return false, fmt.Errorf("file %s already exist", remoteFilePath)
+	}
+	return true, nil
+}
+
 func (sc *SFTPClient) RemoveFile(targetFilePath string) error {
 	return sc.Client.Remove(targetFilePath)
 }
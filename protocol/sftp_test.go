@@ -0,0 +1,188 @@
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTPClient 는 메모리에 파일을 저장하는 sftp 서버에 연결된 클라이언트를 반환
+func newTestSFTPClient(t *testing.T) *SFTPClient {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go func() {
+		_ = server.Serve()
+	}()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn, sftp.UseConcurrentWrites(true))
+	if err != nil {
+		t.Fatalf("fail to create sftp client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+
+	return &SFTPClient{ConnInfo: &ConnectionInfo{}, Client: client}
+}
+
+func writeTestRemoteFile(t *testing.T, sc *SFTPClient, remotePath string, data []byte) {
+	t.Helper()
+	file, err := sc.Client.OpenFile(remotePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		t.Fatalf("fail to open remote %s file: %v", remotePath, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		t.Fatalf("fail to write remote %s file: %v", remotePath, err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("fail to close remote %s file: %v", remotePath, err)
+	}
+}
+
+func TestSendFileResume(t *testing.T) {
+	// 이어서 보낼 위치가 생기도록 sftpResumeMargin 보다 큰 파일 사용
+	content := bytes.Repeat([]byte("0123456789abcdef"), (sftpResumeMargin+sftpResumeMargin/2)/16)
+	partial := append(bytes.Repeat([]byte("x"), 16), content[16:sftpResumeMargin+sftpResumeMargin/4]...)
+	resumed := append(partial[:16:16], content[16:]...)
+
+	tests := []struct {
+		name        string
+		resume      bool
+		partial     []byte
+		staleSource bool   // partial 파일을 다른 원본에서 보낸 경우
+		want        []byte // 이어서 보내면 partial 파일 앞부분이 그대로 남음
+	}{
+		{name: "send from start", resume: false, partial: partial, want: content},
+		{name: "resume partial", resume: true, partial: partial, want: resumed},
+		{name: "stale partial", resume: true, partial: partial, staleSource: true, want: content},
+		{name: "no partial", resume: true, want: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := newTestSFTPClient(t)
+
+			localPath := filepath.Join(t.TempDir(), "a.jpg")
+			if err := os.WriteFile(localPath, content, 0644); err != nil {
+				t.Fatalf("fail to write local file: %v", err)
+			}
+			localInfo, err := os.Stat(localPath)
+			if err != nil {
+				t.Fatalf("fail to stat local file: %v", err)
+			}
+
+			remotePath := "/DCIM/a.jpg"
+			if err := sc.Client.MkdirAll("/DCIM"); err != nil {
+				t.Fatalf("fail to create remote dir: %v", err)
+			}
+			if tt.partial != nil {
+				writeTestRemoteFile(t, sc, sftpTempPath(remotePath, ".partial"), tt.partial)
+				source := partialSource(localInfo)
+				if tt.staleSource {
+					source = "1 1"
+				}
+				writeTestRemoteFile(t, sc, sftpTempPath(remotePath, ".partial.info"), []byte(source+"\n"))
+			}
+
+			sent, err := sc.SendFile(localPath, remotePath, SendOptions{Resume: tt.resume})
+			if err != nil {
+				t.Fatalf("SendFile() error = %v", err)
+			}
+			if sent != int64(len(content)) {
+				t.Errorf("SendFile() = %d, want %d", sent, len(content))
+			}
+
+			remoteFile, err := sc.Client.Open(remotePath)
+			if err != nil {
+				t.Fatalf("fail to open remote file: %v", err)
+			}
+			defer func() {
+				_ = remoteFile.Close()
+			}()
+			got, err := io.ReadAll(remoteFile)
+			if err != nil {
+				t.Fatalf("fail to read remote file: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("remote file differs from expected content(resume: %v)", tt.resume)
+			}
+			tempSuffixes := []string{".tmp"}
+			if tt.resume {
+				tempSuffixes = append(tempSuffixes, ".partial", ".partial.info")
+			}
+			for _, suffix := range tempSuffixes {
+				if _, err := sc.Client.Stat(sftpTempPath(remotePath, suffix)); err == nil {
+					t.Errorf("%s file remains after SendFile()", sftpTempPath(remotePath, suffix))
+				}
+			}
+		})
+	}
+}
+
+func TestSendFileOverwrite(t *testing.T) {
+	content := []byte("new content")
+
+	tests := []struct {
+		name      string
+		remote    []byte // 원격지에 이미 있는 파일 내용
+		overwrite bool
+		wantSent  int64
+		want      []byte
+		wantErr   bool
+	}{
+		{name: "same size exists", remote: []byte("old content"), wantSent: 0, want: []byte("old content")},
+		{name: "different size exists", remote: []byte("old"), want: []byte("old"), wantErr: true},
+		{name: "overwrite same size", remote: []byte("old content"), overwrite: true, wantSent: int64(len(content)), want: content},
+		{name: "overwrite different size", remote: []byte("old"), overwrite: true, wantSent: int64(len(content)), want: content},
+		{name: "overwrite not exists", overwrite: true, wantSent: int64(len(content)), want: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := newTestSFTPClient(t)
+
+			localPath := filepath.Join(t.TempDir(), "a.jpg")
+			if err := os.WriteFile(localPath, content, 0644); err != nil {
+				t.Fatalf("fail to write local file: %v", err)
+			}
+
+			remotePath := "/DCIM/a.jpg"
+			if err := sc.Client.MkdirAll("/DCIM"); err != nil {
+				t.Fatalf("fail to create remote dir: %v", err)
+			}
+			if tt.remote != nil {
+				writeTestRemoteFile(t, sc, remotePath, tt.remote)
+			}
+
+			sent, err := sc.SendFile(localPath, remotePath, SendOptions{Overwrite: tt.overwrite})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if sent != tt.wantSent {
+				t.Errorf("SendFile() = %d, want %d", sent, tt.wantSent)
+			}
+
+			remoteFile, err := sc.Client.Open(remotePath)
+			if err != nil {
+				t.Fatalf("fail to open remote file: %v", err)
+			}
+			defer func() {
+				_ = remoteFile.Close()
+			}()
+			got, err := io.ReadAll(remoteFile)
+			if err != nil {
+				t.Fatalf("fail to read remote file: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("remote file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
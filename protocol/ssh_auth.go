@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	SSHAuthPublicKey           = "publickey"            // 개인 키 파일
+	SSHAuthAgent               = "agent"                // ssh-agent
+	SSHAuthKeyboardInteractive = "keyboard-interactive" // 모든 질문에 비밀번호로 응답
+	SSHAuthPassword            = "password"             // 비밀번호
+)
+
+type SSHAuthInfo struct {
+	Methods       []string // 시도할 인증 방법 순서
+	KeyFile       string   // 개인 키 파일 경로
+	KeyPassphrase string   // 개인 키 비밀번호
+	AgentSocket   string   // ssh-agent 소켓 경로 (비어 있으면 SSH_AUTH_SOCK 사용)
+}
+
+// sshAuthMethods 는 설정한 순서대로 인증 방법을 만들고 연결 후 정리할 함수를 반환
+// 개인 키와 ssh-agent 는 같은 publickey 방식이라 ssh 패키지가 한 번만 시도하므로 하나로 합침
+func sshAuthMethods(info *ConnectionInfo) ([]ssh.AuthMethod, func(), error) {
+	// 인증 방법을 설정하지 않으면 비밀번호만 사용
+	methods := []string{SSHAuthPassword}
+	if info.SSHAuth != nil && len(info.SSHAuth.Methods) != 0 {
+		methods = info.SSHAuth.Methods
+	}
+
+	var auths []ssh.AuthMethod
+	var signers []ssh.Signer
+	var agentConn net.Conn
+	cleanup := func() {
+		if agentConn != nil {
+			if err := agentConn.Close(); err != nil {
+				log.Printf("fail to close ssh-agent connection: %v", err)
+			}
+		}
+	}
+
+	publicKeyAdded := false
+	addPublicKeys := func() {
+		if !publicKeyAdded {
+			publicKeyAdded = true
+			auths = append(auths, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				return signers, nil
+			}))
+		}
+	}
+
+	for _, method := range methods {
+		switch method {
+		case SSHAuthPublicKey:
+			signer, err := loadPrivateKey(info.SSHAuth.KeyFile, info.SSHAuth.KeyPassphrase)
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			signers = append(signers, signer)
+			addPublicKeys()
+		case SSHAuthAgent:
+			socket := info.SSHAuth.AgentSocket
+			if len(socket) == 0 {
+				socket = os.Getenv("SSH_AUTH_SOCK")
+			}
+			conn, err := net.Dial("unix", socket)
+			if err != nil {
+				cleanup()
+				return nil, nil, errors.Wrapf(err, "fail to connect ssh-agent %s", socket)
+			}
+			agentConn = conn
+
+			agentSigners, err := agent.NewClient(conn).Signers()
+			if err != nil {
+				cleanup()
+				return nil, nil, errors.Wrap(err, "fail to get ssh-agent keys")
+			}
+			signers = append(signers, agentSigners...)
+			addPublicKeys()
+		case SSHAuthKeyboardInteractive:
+			password := info.Password
+			auths = append(auths, ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}))
+		case SSHAuthPassword:
+			auths = append(auths, ssh.Password(info.Password))
+		default:
+			cleanup()
+			return nil, nil, fmt.Errorf("invalid ssh auth method: %s", method)
+		}
+	}
+
+	return auths, cleanup, nil
+}
+
+// loadPrivateKey 는 개인 키 파일을 읽고 비밀번호가 있으면 복호화
+func loadPrivateKey(keyFile, passphrase string) (ssh.Signer, error) {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to read %s private key", keyFile)
+	}
+
+	var signer ssh.Signer
+	if len(passphrase) != 0 {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to parse %s private key", keyFile)
+	}
+
+	return signer, nil
+}
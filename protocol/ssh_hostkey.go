@@ -0,0 +1,166 @@
+package protocol
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	HostKeyKnownHosts  = "known_hosts" // known_hosts 파일에 있는 키만 허용
+	HostKeyFingerprint = "fingerprint" // 설정한 fingerprint 와 같은 키만 허용
+	HostKeyTOFU        = "tofu"        // 처음 연결할 때 키를 known_hosts 에 기록하고 이후에는 known_hosts 와 같이 확인
+	HostKeyInsecure    = "insecure"    // 확인하지 않음
+)
+
+type HostKeyInfo struct {
+	Mode           string // 호스트 키 확인 방법
+	KnownHostsFile string // known_hosts 파일 경로
+	Fingerprint    string // 허용할 호스트 키 fingerprint (SHA256:... 또는 MD5 aa:bb:...)
+}
+
+// HostKeyError 는 호스트 키가 알려진 키와 다를 때 발생
+type HostKeyError struct {
+	Host        string
+	Fingerprint string
+	Expected    []string
+	Source      string
+}
+
+func (e *HostKeyError) Error() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("host key of %s (%s) is not in %s", e.Host, e.Fingerprint, e.Source)
+	}
+	return fmt.Sprintf("host key mismatch for %s: got %s, expected %s in %s (the host may be spoofed, "+
+		"remove the old key only if the host key was changed intentionally)",
+		e.Host, e.Fingerprint, strings.Join(e.Expected, ", "), e.Source)
+}
+
+// tofuMutex 는 known_hosts 파일에 키를 기록할 때 사용
+var tofuMutex sync.Mutex
+
+// hostKeyCallback 은 설정한 방법으로 호스트 키를 확인하는 callback 을 반환
+func hostKeyCallback(info *HostKeyInfo) (ssh.HostKeyCallback, error) {
+	// 설정하지 않으면 확인하지 않음
+	if info == nil {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	switch info.Mode {
+	case HostKeyInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case HostKeyFingerprint:
+		return fingerprintCallback(info.Fingerprint), nil
+	case HostKeyKnownHosts:
+		callback, err := knownhosts.New(info.KnownHostsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to read %s known_hosts file", info.KnownHostsFile)
+		}
+		return knownHostsCallback(callback, info.KnownHostsFile, nil), nil
+	case HostKeyTOFU:
+		if err := createKnownHostsFile(info.KnownHostsFile); err != nil {
+			return nil, err
+		}
+		callback, err := knownhosts.New(info.KnownHostsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to read %s known_hosts file", info.KnownHostsFile)
+		}
+		return knownHostsCallback(callback, info.KnownHostsFile, func(hostname string, key ssh.PublicKey) error {
+			return addKnownHost(info.KnownHostsFile, hostname, key)
+		}), nil
+	default:
+		return nil, fmt.Errorf("invalid host key mode: %s", info.Mode)
+	}
+}
+
+// fingerprintCallback 은 호스트 키가 fingerprint 와 같은지 확인
+func fingerprintCallback(fingerprint string) ssh.HostKeyCallback {
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		if strings.HasPrefix(fingerprint, "SHA256:") {
+			if ssh.FingerprintSHA256(key) == fingerprint {
+				return nil
+			}
+		} else if strings.EqualFold(strings.TrimPrefix(fingerprint, "MD5:"), ssh.FingerprintLegacyMD5(key)) {
+			return nil
+		}
+
+		return &HostKeyError{
+			Host:        hostname,
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Expected:    []string{fingerprint},
+			Source:      "config",
+		}
+	}
+}
+
+// knownHostsCallback 은 known_hosts 로 호스트 키를 확인하고 처음 보는 호스트면 unknown 을 호출
+func knownHostsCallback(callback ssh.HostKeyCallback, file string, unknown func(hostname string, key ssh.PublicKey) error) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if err == nil || !errors.As(err, &keyErr) {
+			return err
+		}
+
+		// 처음 보는 호스트
+		if len(keyErr.Want) == 0 && unknown != nil {
+			return unknown(hostname, key)
+		}
+
+		hostKeyErr := &HostKeyError{
+			Host:        hostname,
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Source:      file,
+		}
+		for _, want := range keyErr.Want {
+			hostKeyErr.Expected = append(hostKeyErr.Expected, fmt.Sprintf("%s (%s:%d)", ssh.FingerprintSHA256(want.Key), want.Filename, want.Line))
+		}
+		return hostKeyErr
+	}
+}
+
+// createKnownHostsFile 은 known_hosts 파일이 없으면 빈 파일을 생성
+func createKnownHostsFile(file string) error {
+	if FileExists(file) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return errors.Wrapf(err, "fail to make %s directory", filepath.Dir(file))
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "fail to create %s known_hosts file", file)
+	}
+	return f.Close()
+}
+
+// addKnownHost 는 처음 연결한 호스트의 키를 known_hosts 파일에 기록
+func addKnownHost(file, hostname string, key ssh.PublicKey) error {
+	tofuMutex.Lock()
+	defer tofuMutex.Unlock()
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "fail to open %s known_hosts file", file)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("fail to close %s known_hosts file: %v", file, err)
+		}
+	}()
+
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)); err != nil {
+		return errors.Wrapf(err, "fail to write %s known_hosts file", file)
+	}
+	log.Printf("trust %s host key %s on first use, saved to %s", hostname, ssh.FingerprintSHA256(key), file)
+
+	return nil
+}
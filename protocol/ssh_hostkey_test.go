@@ -0,0 +1,117 @@
+package protocol
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate ed25519 key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("fail to make ssh public key: %v", err)
+	}
+	return key
+}
+
+func TestFingerprintCallback(t *testing.T) {
+	key := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+	remote := &net.TCPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 22}
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		wantErr     bool
+	}{
+		{name: "sha256", fingerprint: ssh.FingerprintSHA256(key)},
+		{name: "md5", fingerprint: ssh.FingerprintLegacyMD5(key)},
+		{name: "md5 prefix", fingerprint: "MD5:" + ssh.FingerprintLegacyMD5(key)},
+		{name: "other sha256", fingerprint: ssh.FingerprintSHA256(otherKey), wantErr: true},
+		{name: "other md5", fingerprint: ssh.FingerprintLegacyMD5(otherKey), wantErr: true},
+		{name: "empty", fingerprint: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fingerprintCallback(tt.fingerprint)("192.168.0.2:22", remote, key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fingerprintCallback(%q) error = %v, wantErr %v", tt.fingerprint, err, tt.wantErr)
+			}
+			var hostKeyErr *HostKeyError
+			if err != nil && !errors.As(err, &hostKeyErr) {
+				t.Errorf("fingerprintCallback(%q) error = %T, want *HostKeyError", tt.fingerprint, err)
+			}
+		})
+	}
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	knownKey := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("known.example.com:22")}, knownKey)
+	if err := os.WriteFile(file, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("fail to write %s known_hosts file: %v", file, err)
+	}
+
+	tests := []struct {
+		name        string
+		hostname    string
+		key         ssh.PublicKey
+		hasUnknown  bool
+		wantUnknown bool
+		wantErr     bool
+		wantHostErr bool
+	}{
+		{name: "known host", hostname: "known.example.com:22", key: knownKey},
+		{name: "changed key", hostname: "known.example.com:22", key: otherKey, wantErr: true, wantHostErr: true},
+		{name: "changed key with unknown", hostname: "known.example.com:22", key: otherKey, hasUnknown: true, wantErr: true, wantHostErr: true},
+		{name: "unknown host", hostname: "new.example.com:22", key: otherKey, wantErr: true, wantHostErr: true},
+		{name: "unknown host with unknown", hostname: "new.example.com:22", key: otherKey, hasUnknown: true, wantUnknown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := knownhosts.New(file)
+			if err != nil {
+				t.Fatalf("fail to read %s known_hosts file: %v", file, err)
+			}
+
+			calledUnknown := false
+			var unknown func(hostname string, key ssh.PublicKey) error
+			if tt.hasUnknown {
+				unknown = func(string, ssh.PublicKey) error {
+					calledUnknown = true
+					return nil
+				}
+			}
+
+			remote := &net.TCPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 22}
+			err = knownHostsCallback(callback, file, unknown)(tt.hostname, remote, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("knownHostsCallback(%s) error = %v, wantErr %v", tt.hostname, err, tt.wantErr)
+			}
+			var hostKeyErr *HostKeyError
+			if errors.As(err, &hostKeyErr) != tt.wantHostErr {
+				t.Errorf("knownHostsCallback(%s) error = %v, want *HostKeyError %v", tt.hostname, err, tt.wantHostErr)
+			}
+			if calledUnknown != tt.wantUnknown {
+				t.Errorf("knownHostsCallback(%s) called unknown = %v, want %v", tt.hostname, calledUnknown, tt.wantUnknown)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,14 +10,24 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 type SynologyClient struct {
-	ConnInfo *ConnectionInfo
-	SessID   string
+	ConnInfo   *ConnectionInfo
+	SessID     string
+	PageSize   int
+	MD5Timeout time.Duration // 파일 하나의 MD5 계산을 기다릴 최대 시간
+
+	httpClient *http.Client
+	apis       map[string]*APIInfo
+	deviceID   string
+	mu         sync.RWMutex
 }
 
 type FileListResponse struct {
@@ -24,7 +36,8 @@ type FileListResponse struct {
 		Offset int     `json:"offset"`
 		Total  int     `json:"total"`
 	} `json:"data"`
-	Success bool `json:"success"`
+	Success bool           `json:"success"`
+	Error   *ErrorResponse `json:"error,omitempty"`
 }
 
 type File struct {
@@ -32,43 +45,274 @@ type File struct {
 	Path       string `json:"path"`
 	IsDir      bool   `json:"isdir"`
 	Additional struct {
-		Size uint64 `json:"size"`
+		Size uint64   `json:"size"`
+		Time FileTime `json:"time"`
 	} `json:"additional"`
 	List *FileListResponse
 }
 
-type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+type FileTime struct {
+	Atime  int64 `json:"atime"`
+	Mtime  int64 `json:"mtime"`
+	Ctime  int64 `json:"ctime"`
+	Crtime int64 `json:"crtime"`
+}
+
+// ModTime 은 수정 시간을 반환하며 수정 시간이 없으면 생성 시간을 사용
+func (t FileTime) ModTime() time.Time {
+	if t.Mtime == 0 {
+		return time.Unix(t.Crtime, 0)
+	}
+	return time.Unix(t.Mtime, 0)
+}
+
+// AccessTime 은 접근 시간을 반환하며 접근 시간이 없으면 수정 시간을 사용
+func (t FileTime) AccessTime() time.Time {
+	if t.Atime == 0 {
+		return t.ModTime()
+	}
+	return time.Unix(t.Atime, 0)
 }
 
-func NewSynologyClient(info *ConnectionInfo) (*SynologyClient, error) {
-	sid, err := newSessionID(info)
+const defaultPageSize = 1000
+
+func NewSynologyClient(ctx context.Context, info *ConnectionInfo) (*SynologyClient, error) {
+	httpClient, err := newHTTPClient(info)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make http client")
+	}
+
+	client := &SynologyClient{
+		ConnInfo:   info,
+		httpClient: httpClient,
+	}
+
+	// 사용할 API 의 경로와 버전 조회
+	if err := client.queryAPIInfo(ctx); err != nil {
+		return nil, errors.Wrap(err, "fail to query api info")
+	}
+
+	// 저장된 기기 토큰 불러오기
+	if info.OTP != nil && len(info.OTP.DeviceTokenFile) != 0 && FileExists(info.OTP.DeviceTokenFile) {
+		data, err := os.ReadFile(info.OTP.DeviceTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to read %s device token file: %v", info.OTP.DeviceTokenFile, err)
+		}
+		client.deviceID = strings.TrimSpace(string(data))
+	}
+
+	client.SessID, err = client.newSessionID(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "fail to get new session id")
 	}
 
-	return &SynologyClient{
-		ConnInfo: info,
-		SessID:   sid,
-	}, nil
+	return client, nil
 }
 
-func newSessionID(info *ConnectionInfo) (string, error) {
-	// File Station API 인증 정보
+func (client *SynologyClient) apiURL(cgi string, params url.Values) string {
+	scheme := client.ConnInfo.Scheme
+	if len(scheme) == 0 {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s:%d/webapi/%s?%s", scheme, client.ConnInfo.IP, client.ConnInfo.Port, cgi, params.Encode())
+}
+
+func (client *SynologyClient) sessionID() string {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	return client.SessID
+}
+
+// renewSession 은 만료된 세션을 새 세션으로 교체
+// 다른 요청이 이미 세션을 갱신했다면 다시 로그인하지 않음
+func (client *SynologyClient) renewSession(ctx context.Context, expiredSID string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.SessID != expiredSID {
+		return nil
+	}
+
+	sid, err := client.newSessionID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fail to renew session id")
+	}
+	client.SessID = sid
+	log.Print("synology session renewed")
+
+	return nil
+}
+
+// withSession 은 세션이 만료되었다는 응답을 받으면 다시 로그인한 뒤 요청을 한번 더 시도
+func (client *SynologyClient) withSession(ctx context.Context, request func(sid string) error) error {
+	sid := client.sessionID()
+	err := request(sid)
+
+	var synoErr *SynologyError
+	if !errors.As(err, &synoErr) || !synoErr.SessionExpired() {
+		return err
+	}
+	log.Printf("synology session expired, login again: %v", synoErr)
+
+	if err := client.renewSession(ctx, sid); err != nil {
+		return err
+	}
+	return request(client.sessionID())
+}
+
+// callAPI 는 API 를 호출하고 응답의 data 를 result 에 담음
+func (client *SynologyClient) callAPI(ctx context.Context, api, method string, params url.Values, result interface{}) error {
+	return client.withSession(ctx, func(sid string) error {
+		cgiPath, apiInfo, err := client.apiValues(api, method)
+		if err != nil {
+			return err
+		}
+		for key := range params {
+			apiInfo.Set(key, params.Get(key))
+		}
+		apiInfo.Set("_sid", sid)
+
+		synoURL := client.apiURL(cgiPath, apiInfo)
+		resp, err := getWithContext(ctx, client.httpClient, synoURL)
+		if err != nil {
+			return errors.Wrapf(err, "fail to get %s url", synoURL)
+		}
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.Printf("fail to close %s request: %v", synoURL, err)
+			}
+		}()
+
+		// API 응답 해석
+		var apiResponse struct {
+			Data    json.RawMessage `json:"data"`
+			Success bool            `json:"success"`
+			Error   *ErrorResponse  `json:"error,omitempty"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+			return fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+		}
+		if !apiResponse.Success {
+			return newSynologyError(api, apiResponse.Error)
+		}
+		if result != nil && len(apiResponse.Data) != 0 {
+			if err := json.Unmarshal(apiResponse.Data, result); err != nil {
+				log.Printf("error to unmarshal data: %s", string(apiResponse.Data))
+				return fmt.Errorf("fail to unmarshal %s response data: %v", synoURL, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (client *SynologyClient) Logout(ctx context.Context) error {
+	// File Station API 로그아웃 정보
+	cgiPath, apiInfo, err := client.apiValues("SYNO.API.Auth", "logout")
+	if err != nil {
+		return err
+	}
+	apiInfo.Set("session", "FileStation")
+	apiInfo.Set("_sid", client.sessionID())
+
+	// 로그아웃 API 호출
+	synoURL := client.apiURL(cgiPath, apiInfo)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
+	if err != nil {
+		return errors.Wrapf(err, "fail to get %s url", synoURL)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+	}()
+
+	// API 응답 해석
+	var logoutResponse struct {
+		Success bool           `json:"success"`
+		Error   *ErrorResponse `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&logoutResponse); err != nil {
+		return fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+	}
+	if !logoutResponse.Success {
+		return errors.Wrap(newSynologyError("SYNO.API.Auth", logoutResponse.Error), "fail to logout")
+	}
+
+	return nil
+}
+
+func (client *SynologyClient) newSessionID(ctx context.Context) (string, error) {
+	otp := client.ConnInfo.OTP
+
+	// 신뢰할 수 있는 기기 토큰으로 로그인
+	if len(client.deviceID) != 0 {
+		apiInfo := url.Values{}
+		apiInfo.Set("device_name", otp.DeviceName)
+		apiInfo.Set("device_id", client.deviceID)
+
+		sid, _, err := client.login(ctx, apiInfo)
+		if err == nil {
+			return sid, nil
+		}
+		if len(otp.Secret) == 0 {
+			return "", err
+		}
+		// 토큰이 만료되거나 취소되었으면 OTP 로 다시 로그인
+		log.Printf("fail to login with device token, retry with otp code: %v", err)
+	}
+
+	// 2단계 인증 코드 설정
 	apiInfo := url.Values{}
-	apiInfo.Set("api", "SYNO.API.Auth")
-	apiInfo.Set("version", "6")
-	apiInfo.Set("method", "login")
-	apiInfo.Set("account", info.Username)
-	apiInfo.Set("passwd", info.Password)
+	if otp != nil && len(otp.Secret) != 0 {
+		code, err := GenerateTOTP(otp.Secret, time.Now())
+		if err != nil {
+			return "", errors.Wrap(err, "fail to generate otp code")
+		}
+		apiInfo.Set("otp_code", code)
+		apiInfo.Set("enable_device_token", "yes")
+		apiInfo.Set("device_name", otp.DeviceName)
+	}
+
+	sid, did, err := client.login(ctx, apiInfo)
+	if err != nil {
+		return "", err
+	}
+
+	// 다음 로그인부터 OTP 없이 로그인할 수 있도록 기기 토큰 저장
+	if otp != nil && len(did) != 0 && did != client.deviceID {
+		client.deviceID = did
+		if len(otp.DeviceTokenFile) != 0 {
+			if err := os.WriteFile(otp.DeviceTokenFile, []byte(did), 0600); err != nil {
+				log.Printf("fail to write %s device token file: %v", otp.DeviceTokenFile, err)
+			} else {
+				log.Printf("save device token to %s", otp.DeviceTokenFile)
+			}
+		}
+	}
+
+	return sid, nil
+}
+
+func (client *SynologyClient) login(ctx context.Context, params url.Values) (string, string, error) {
+	// File Station API 인증 정보
+	cgiPath, apiInfo, err := client.apiValues("SYNO.API.Auth", "login")
+	if err != nil {
+		return "", "", err
+	}
+	apiInfo.Set("account", client.ConnInfo.Username)
+	apiInfo.Set("passwd", client.ConnInfo.Password)
 	apiInfo.Set("session", "FileStation")
+	apiInfo.Set("format", "sid")
+	for key := range params {
+		apiInfo.Set(key, params.Get(key))
+	}
 
 	// 인증 API 호출
-	synoURL := fmt.Sprintf("http://%s:%d/webapi/auth.cgi?%s", info.IP, info.Port, apiInfo.Encode())
-	resp, err := http.Get(synoURL)
+	synoURL := client.apiURL(cgiPath, apiInfo)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
 	if err != nil {
-		return "", fmt.Errorf("fail to get %s url: %v", synoURL, err)
+		return "", "", errors.Wrapf(err, "fail to get %s url", synoURL)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -80,40 +324,113 @@ func newSessionID(info *ConnectionInfo) (string, error) {
 	var authResponse struct {
 		Data struct {
 			Sid string `json:"sid"`
+			Did string `json:"did"`
 		} `json:"data"`
+		Success bool           `json:"success"`
+		Error   *ErrorResponse `json:"error,omitempty"`
 	}
 	err = json.NewDecoder(resp.Body).Decode(&authResponse)
 	if err != nil {
-		return "", fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+		return "", "", fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
 	}
 
-	if authResponse.Data.Sid == "" {
+	if !authResponse.Success {
 		// 인증 실패 처리
-		var errorResponse ErrorResponse
-		err = json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return "", "", errors.Wrap(newSynologyError("SYNO.API.Auth", authResponse.Error), "authentication failed")
+	}
+	if authResponse.Data.Sid == "" {
+		return "", "", errors.New("authentication failed: empty session id")
+	}
+
+	return authResponse.Data.Sid, authResponse.Data.Did, nil
+}
+
+func (client *SynologyClient) GetFileList(ctx context.Context, folderPath string) (*FileListResponse, error) {
+	fileListResponse := &FileListResponse{Success: true}
+
+	// 모든 페이지를 하나의 응답으로 병합
+	err := client.ForEachFile(ctx, folderPath, func(file *File) error {
+		fileListResponse.Data.Files = append(fileListResponse.Data.Files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fileListResponse.Data.Total = len(fileListResponse.Data.Files)
+
+	return fileListResponse, nil
+}
+
+// ForEachFile 은 폴더의 파일 목록을 페이지 단위로 조회하면서 항목마다 fn 을 호출
+func (client *SynologyClient) ForEachFile(ctx context.Context, folderPath string, fn func(file *File) error) error {
+	pageSize := client.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	offset := 0
+	for {
+		page, err := client.getFileListPage(ctx, folderPath, offset, pageSize)
 		if err != nil {
-			return "", fmt.Errorf("fail to decode %s authentication failed response body: %v", synoURL, err)
+			return err
+		}
+
+		for _, file := range page.Data.Files {
+			if err := fn(file); err != nil {
+				return err
+			}
+		}
+
+		// 마지막 페이지 확인 (total 이 없는 응답은 덜 채워진 페이지로 확인)
+		offset += len(page.Data.Files)
+		if len(page.Data.Files) == 0 {
+			return nil
+		}
+		if page.Data.Total > 0 {
+			if offset >= page.Data.Total {
+				return nil
+			}
+		} else if len(page.Data.Files) < pageSize {
+			return nil
 		}
-		return "", fmt.Errorf("authentication failed: %v", errorResponse)
 	}
+}
 
-	return authResponse.Data.Sid, nil
+func (client *SynologyClient) getFileListPage(ctx context.Context, folderPath string, offset, limit int) (*FileListResponse, error) {
+	var fileListResponse *FileListResponse
+	err := client.withSession(ctx, func(sid string) error {
+		var err error
+		fileListResponse, err = client.requestFileListPage(ctx, sid, folderPath, offset, limit)
+		if err != nil {
+			return err
+		}
+		if !fileListResponse.Success {
+			return newSynologyError("SYNO.FileStation.List", fileListResponse.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to list %s folder", folderPath)
+	}
+	return fileListResponse, nil
 }
 
-func (client *SynologyClient) GetFileList(folderPath string) (*FileListResponse, error) {
+func (client *SynologyClient) requestFileListPage(ctx context.Context, sid, folderPath string, offset, limit int) (*FileListResponse, error) {
 	// FileStation.List API 호출
-	listInfo := url.Values{}
-	listInfo.Set("api", "SYNO.FileStation.List")
-	listInfo.Set("version", "1")
-	listInfo.Set("method", "list")
+	cgiPath, listInfo, err := client.apiValues("SYNO.FileStation.List", "list")
+	if err != nil {
+		return nil, err
+	}
 	listInfo.Set("folder_path", folderPath)
-	listInfo.Set("_sid", client.SessID)
-	listInfo.Set("additional", "size")
+	listInfo.Set("offset", strconv.Itoa(offset))
+	listInfo.Set("limit", strconv.Itoa(limit))
+	listInfo.Set("_sid", sid)
+	listInfo.Set("additional", "size,time")
 
-	synoURL := fmt.Sprintf("http://%s:%d/webapi/entry.cgi?%s", client.ConnInfo.IP, client.ConnInfo.Port, listInfo.Encode())
-	resp, err := http.Get(synoURL)
+	synoURL := client.apiURL(cgiPath, listInfo)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
 	if err != nil {
-		return nil, fmt.Errorf("fail to get %s url: %v", synoURL, err)
+		return nil, errors.Wrapf(err, "fail to get %s url", synoURL)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -134,50 +451,127 @@ func (client *SynologyClient) GetFileList(folderPath string) (*FileListResponse,
 		return nil, fmt.Errorf("fail to unmarshal %s response body: %v", synoURL, err)
 	}
 
-	if !fileListResponse.Success {
-		log.Printf("success flag is false: %v", fileListResponse)
-	}
 	return fileListResponse, nil
 }
 
-func (client *SynologyClient) DownloadFile(filePath, destPath string) (string, int64, error) {
-	// FileStation.Download API 호출
-	downloadInfo := url.Values{}
-	downloadInfo.Set("api", "SYNO.FileStation.Download")
-	downloadInfo.Set("version", "1")
-	downloadInfo.Set("method", "download")
-	downloadInfo.Set("path", filePath)
-	downloadInfo.Set("_sid", client.SessID)
+func (client *SynologyClient) DownloadFile(ctx context.Context, file *File, destPath string) (string, int64, error) {
+	return downloadFile(ctx, client.ConnInfo.Transfer, file, destPath, func(ctx context.Context, offset int64) (*http.Response, error) {
+		var resp *http.Response
+		err := client.withSession(ctx, func(sid string) error {
+			var err error
+			resp, err = client.requestDownload(ctx, sid, file.Path, offset)
+			return err
+		})
+		return resp, err
+	})
+}
 
-	synoURL := fmt.Sprintf("http://%s:%d/webapi/entry.cgi?%s", client.ConnInfo.IP, client.ConnInfo.Port, downloadInfo.Encode())
-	resp, err := http.Get(synoURL)
-	if err != nil {
-		return "", 0, fmt.Errorf("fail to get %s url: %v", synoURL, err)
+// downloadFile 은 request 로 받은 응답을 destPath 에 저장하며 중단된 파일이 있으면 offset 부터 이어받음
+// 전송이 멈추거나 limit 의 최대 전송 시간을 넘기면 요청을 취소하고 TransferError 를 반환
+func downloadFile(ctx context.Context, limit *TransferLimit, file *File, destPath string, request func(ctx context.Context, offset int64) (*http.Response, error)) (string, int64, error) {
+	filePath := file.Path
+	tempPath := destPath + ".download"
+
+	// 이전에 받다가 중단된 파일이 있으면 이어받기
+	var offset int64
+	if tempInfo, err := os.Stat(tempPath); err == nil {
+		offset = tempInfo.Size()
+		if uint64(offset) > file.Additional.Size {
+			log.Printf("partial %s file is bigger than source, download again", tempPath)
+			offset = 0
+		}
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("fail to close %s request: %v", synoURL, err)
+
+	size := offset
+	completed := false
+	if uint64(offset) != file.Additional.Size || offset == 0 {
+		// 전송 감시
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		watcher := watchTransfer(limit, filePath, int64(file.Additional.Size)-offset, cancel)
+		defer watcher.Stop()
+
+		resp, err := request(ctx, offset)
+		if err != nil {
+			return "", 0, watcher.Err(err)
 		}
-	}()
 
-	// 파일 다운로드
-	tempPath := destPath + ".download"
-	out, err := os.Create(tempPath)
-	if err != nil {
-		return "", 0, fmt.Errorf("fail to create %s file: %v", tempPath, err)
-	}
-	defer func() {
-		if err := out.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
-			log.Printf("fail to close %s file: %v", tempPath, err)
+		// 요청한 위치부터 오지 않았으면 처음부터 다시 요청
+		if resp.StatusCode == http.StatusPartialContent {
+			if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+				log.Printf("%s content range %q does not start at %d byte, download again", filePath, resp.Header.Get("Content-Range"), offset)
+				if err := resp.Body.Close(); err != nil {
+					log.Printf("fail to close %s download request: %v", filePath, err)
+				}
+				if resp, err = request(ctx, 0); err != nil {
+					return "", 0, watcher.Err(err)
+				}
+			}
 		}
-	}()
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.Printf("fail to close %s download request: %v", filePath, err)
+			}
+		}()
 
-	size, err := io.Copy(out, resp.Body)
-	if err != nil {
-		return "", 0, fmt.Errorf("fail to copy %s file: %v", tempPath, err)
+		// 서버가 Range 요청을 지원하지 않으면 처음부터 다시 받음
+		flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if resp.StatusCode != http.StatusPartialContent {
+			if offset > 0 {
+				log.Printf("server does not support range request, download %s again", filePath)
+			}
+			flag = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			offset = 0
+		} else {
+			log.Printf("resume %s download from %d byte", filePath, offset)
+		}
+
+		// 받을 크기가 다르면 파일이 아닌 응답이므로 임시 파일을 건드리지 않음
+		if resp.ContentLength >= 0 && uint64(offset+resp.ContentLength) != file.Additional.Size {
+			return "", 0, &SizeMismatchError{
+				Path:     filePath,
+				Expected: file.Additional.Size,
+				Actual:   uint64(offset + resp.ContentLength),
+			}
+		}
+
+		// 파일 다운로드
+		out, err := os.OpenFile(tempPath, flag, 0644)
+		if err != nil {
+			return "", 0, fmt.Errorf("fail to open %s file: %v", tempPath, err)
+		}
+		defer func() {
+			if err := out.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+				log.Printf("fail to close %s file: %v", tempPath, err)
+			}
+		}()
+
+		written, err := io.Copy(out, watcher.Reader(resp.Body))
+		watcher.Stop()
+		if err != nil {
+			return "", 0, watcher.Err(errors.Wrapf(err, "fail to copy %s file", tempPath))
+		}
+		if err := out.Close(); err != nil {
+			log.Printf("fail to close %s file: %v", tempPath, err)
+		}
+		size = offset + written
+		completed = true
 	}
-	if err := out.Close(); err != nil {
-		log.Printf("fail to close %s file: %v", tempPath, err)
+
+	// 파일 크기 확인
+	if uint64(size) != file.Additional.Size {
+		// 끝까지 받았는데 크기가 다르면 잘못된 내용이므로 이어받지 않음
+		if uint64(size) > file.Additional.Size || completed {
+			// 이어받을 수 없으므로 삭제
+			if err := os.Remove(tempPath); err != nil {
+				log.Printf("fail to remove %s file: %v", tempPath, err)
+			}
+		}
+		return "", 0, &SizeMismatchError{
+			Path:     filePath,
+			Expected: file.Additional.Size,
+			Actual:   uint64(size),
+		}
 	}
 
 	// 방어 코드
@@ -208,3 +602,94 @@ func (client *SynologyClient) DownloadFile(filePath, destPath string) (string, i
 
 	return destPath, size, nil
 }
+
+func (client *SynologyClient) requestDownload(ctx context.Context, sid, filePath string, offset int64) (*http.Response, error) {
+	// FileStation.Download API 호출
+	cgiPath, downloadInfo, err := client.apiValues("SYNO.FileStation.Download", "download")
+	if err != nil {
+		return nil, err
+	}
+	downloadInfo.Set("path", filePath)
+	downloadInfo.Set("mode", "download")
+	downloadInfo.Set("_sid", sid)
+
+	synoURL := client.apiURL(cgiPath, downloadInfo)
+	return requestRange(ctx, client.httpClient, "SYNO.FileStation.Download", synoURL, offset)
+}
+
+// requestRange 는 offset 부터 받는 다운로드 요청을 보내며 이어받을 범위가 잘못되었으면 처음부터 다시 요청
+func requestRange(ctx context.Context, httpClient *http.Client, api, synoURL string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, synoURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to make %s request", synoURL)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get %s url", synoURL)
+	}
+
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+		return requestRange(ctx, httpClient, api, synoURL, 0)
+	}
+
+	return checkDownloadResponse(api, synoURL, resp)
+}
+
+// contentRangeStart 는 "bytes 100-199/200" 형식의 Content-Range 에서 시작 위치를 반환
+func contentRangeStart(contentRange string) (int64, bool) {
+	rangeSpec, ok := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rangeSpec, "-")
+	if !ok {
+		return 0, false
+	}
+	result, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil || result < 0 {
+		return 0, false
+	}
+	return result, true
+}
+
+// checkDownloadResponse 는 다운로드 응답이 파일 대신 JSON 에러 응답인지 확인
+func checkDownloadResponse(api, synoURL string, resp *http.Response) (*http.Response, error) {
+	// 실패하면 파일 대신 JSON 에러 응답이 옴
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		body, err := io.ReadAll(resp.Body)
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("fail to close %s request: %v", synoURL, closeErr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fail to read %s response body: %v", synoURL, err)
+		}
+
+		var errorResponse struct {
+			Success bool           `json:"success"`
+			Error   *ErrorResponse `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal(body, &errorResponse); err == nil && !errorResponse.Success && errorResponse.Error != nil {
+			return nil, newSynologyError(api, errorResponse.Error)
+		}
+
+		// 에러 응답이 아니면 JSON 파일 자체를 다운로드 한 것
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// 파일이 아닌 응답 (서버 오류 페이지 등)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+		return nil, &StatusError{URL: synoURL, StatusCode: resp.StatusCode}
+	}
+
+	return resp, nil
+}
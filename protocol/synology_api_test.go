@@ -0,0 +1,343 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// testNAS 는 SYNO.API.Info 와 로그인에 응답하고 나머지 API 는 handlers 로 넘기는 테스트 서버
+type testNAS struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	logins int
+}
+
+func newTestNAS(t *testing.T, handlers map[string]http.HandlerFunc) *testNAS {
+	t.Helper()
+	nas := &testNAS{}
+	nas.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api := r.URL.Query().Get("api")
+		switch {
+		case api == "SYNO.API.Info":
+			data := make(map[string]*APIInfo)
+			for name, supported := range supportedAPIs {
+				data[name] = &APIInfo{Path: "entry.cgi", MinVersion: 1, MaxVersion: supported.MaxVersion}
+			}
+			writeTestResponse(w, data)
+		case api == "SYNO.API.Auth" && r.URL.Query().Get("method") == "login":
+			nas.mu.Lock()
+			nas.logins++
+			sid := fmt.Sprintf("sid-%d", nas.logins)
+			nas.mu.Unlock()
+			writeTestResponse(w, map[string]string{"sid": sid})
+		case handlers[api] != nil:
+			handlers[api](w, r)
+		default:
+			http.Error(w, "unknown api "+api, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(nas.Close)
+	return nas
+}
+
+// Logins 는 지금까지 로그인한 횟수를 반환
+func (nas *testNAS) Logins() int {
+	nas.mu.Lock()
+	defer nas.mu.Unlock()
+	return nas.logins
+}
+
+func (nas *testNAS) Client(t *testing.T) *SynologyClient {
+	t.Helper()
+	host, port, err := net.SplitHostPort(nas.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("fail to split %s address: %v", nas.Listener.Addr(), err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatalf("fail to parse %s port: %v", port, err)
+	}
+
+	client, err := NewSynologyClient(context.Background(), &ConnectionInfo{
+		IP:       host,
+		Port:     portNumber,
+		Scheme:   "http",
+		Username: "user",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatalf("fail to make synology client: %v", err)
+	}
+	return client
+}
+
+func writeTestResponse(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+}
+
+func TestForEachFilePaging(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     int
+		pageSize  int
+		withTotal bool
+		wantPages int
+	}{
+		{name: "empty folder", files: 0, pageSize: 3, withTotal: true, wantPages: 1},
+		{name: "one page", files: 2, pageSize: 3, withTotal: true, wantPages: 1},
+		{name: "several pages", files: 7, pageSize: 3, withTotal: true, wantPages: 3},
+		{name: "exact pages", files: 6, pageSize: 3, withTotal: true, wantPages: 2},
+		{name: "several pages without total", files: 7, pageSize: 3, wantPages: 3},
+		{name: "exact pages without total", files: 6, pageSize: 3, wantPages: 3},
+		{name: "empty folder without total", files: 0, pageSize: 3, wantPages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			nas := newTestNAS(t, map[string]http.HandlerFunc{
+				"SYNO.FileStation.List": func(w http.ResponseWriter, r *http.Request) {
+					pages++
+					offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+					limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+					data := map[string]interface{}{"offset": offset}
+					files := []*File{}
+					for i := offset; i < tt.files && i < offset+limit; i++ {
+						files = append(files, &File{Name: strconv.Itoa(i), Path: "/photo/" + strconv.Itoa(i)})
+					}
+					data["files"] = files
+					if tt.withTotal {
+						data["total"] = tt.files
+					}
+					writeTestResponse(w, data)
+				},
+			})
+			client := nas.Client(t)
+			client.PageSize = tt.pageSize
+
+			fileList, err := client.GetFileList(context.Background(), "/photo")
+			if err != nil {
+				t.Fatalf("GetFileList() error = %v", err)
+			}
+			if len(fileList.Data.Files) != tt.files || fileList.Data.Total != tt.files {
+				t.Errorf("GetFileList() = %d files (total %d), want %d", len(fileList.Data.Files), fileList.Data.Total, tt.files)
+			}
+			for i, file := range fileList.Data.Files {
+				if file.Name != strconv.Itoa(i) {
+					t.Errorf("GetFileList() file %d = %s, want %d", i, file.Name, i)
+				}
+			}
+			if pages != tt.wantPages {
+				t.Errorf("GetFileList() requested %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func writeTestError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": map[string]int{"code": code}})
+}
+
+func TestWithSessionRenew(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int  // 첫 세션으로 요청했을 때 에러 코드
+		alwaysFail bool // 새 세션으로도 실패
+		wantLogins int
+		wantErr    bool
+	}{
+		{name: "valid session", wantLogins: 1},
+		{name: "session timeout", code: 106, wantLogins: 2},
+		{name: "duplicate login", code: 107, wantLogins: 2},
+		{name: "invalid session", code: 119, wantLogins: 2},
+		{name: "expired again", code: 119, alwaysFail: true, wantLogins: 2, wantErr: true},
+		{name: "permission denied", code: 105, wantLogins: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nas := newTestNAS(t, map[string]http.HandlerFunc{
+				"SYNO.FileStation.List": func(w http.ResponseWriter, r *http.Request) {
+					if tt.code != 0 && (tt.alwaysFail || r.URL.Query().Get("_sid") == "sid-1") {
+						writeTestError(w, tt.code)
+						return
+					}
+					writeTestResponse(w, map[string]interface{}{"files": []*File{{Name: "a.jpg", Path: "/photo/a.jpg"}}, "total": 1})
+				},
+			})
+			client := nas.Client(t)
+
+			fileList, err := client.GetFileList(context.Background(), "/photo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFileList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(fileList.Data.Files) != 1 {
+				t.Errorf("GetFileList() = %d files, want 1", len(fileList.Data.Files))
+			}
+			if nas.Logins() != tt.wantLogins {
+				t.Errorf("logged in %d times, want %d", nas.Logins(), tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	const content = "0123456789abcdefghij"
+	serveContent := func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "a.jpg", time.Time{}, strings.NewReader(content))
+	}
+
+	tests := []struct {
+		name      string
+		partial   string // 이전에 받다가 중단된 .download 파일 내용
+		handler   http.HandlerFunc
+		wantRange string // 첫 요청의 Range 헤더
+		wantErr   bool
+		wantTemp  string // 실패했을 때 남아 있어야 하는 .download 파일 내용 (없으면 삭제)
+	}{
+		{name: "full download", handler: serveContent},
+		{name: "resume", partial: content[:10], handler: serveContent, wantRange: "bytes=10-"},
+		{
+			name:    "range not supported",
+			partial: content[:5],
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, content)
+			},
+			wantRange: "bytes=5-",
+		},
+		{
+			name:    "range not satisfiable",
+			partial: content[:10],
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if len(r.Header.Get("Range")) != 0 {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				serveContent(w, r)
+			},
+			wantRange: "bytes=10-",
+		},
+		{
+			name:    "content range from other offset",
+			partial: content[:10],
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if len(r.Header.Get("Range")) != 0 {
+					w.Header().Set("Content-Range", fmt.Sprintf("bytes 5-19/%d", len(content)))
+					w.WriteHeader(http.StatusPartialContent)
+					_, _ = io.WriteString(w, content[5:])
+					return
+				}
+				serveContent(w, r)
+			},
+			wantRange: "bytes=10-",
+		},
+		{
+			name:    "server error",
+			partial: content[:5],
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			},
+			wantRange: "bytes=5-",
+			wantErr:   true,
+			wantTemp:  content[:5],
+		},
+		{
+			name:    "html error page",
+			partial: content[:5],
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = io.WriteString(w, "<html>error</html>")
+			},
+			wantRange: "bytes=5-",
+			wantErr:   true,
+			wantTemp:  content[:5],
+		},
+		{
+			name: "html error page without length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = io.WriteString(w, "<html>")
+				w.(http.Flusher).Flush()
+				_, _ = io.WriteString(w, "error</html>")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			destPath := filepath.Join(t.TempDir(), "a.jpg")
+			tempPath := destPath + ".download"
+			if len(tt.partial) != 0 {
+				if err := os.WriteFile(tempPath, []byte(tt.partial), 0644); err != nil {
+					t.Fatalf("fail to write %s file: %v", tempPath, err)
+				}
+			}
+
+			file := &File{Name: "a.jpg", Path: "/photo/a.jpg"}
+			file.Additional.Size = uint64(len(content))
+			_, size, err := downloadFile(context.Background(), nil, file, destPath, func(ctx context.Context, offset int64) (*http.Response, error) {
+				return requestRange(ctx, server.Client(), "SYNO.FileStation.Download", server.URL, offset)
+			})
+			if len(ranges) == 0 || ranges[0] != tt.wantRange {
+				t.Errorf("downloadFile() requested ranges %q, want first %q", ranges, tt.wantRange)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				var statusErr *StatusError
+				var sizeErr *SizeMismatchError
+				if !errors.As(err, &statusErr) && !errors.As(err, &sizeErr) {
+					t.Errorf("downloadFile() error = %T, want *StatusError or *SizeMismatchError", err)
+				}
+				data, readErr := os.ReadFile(tempPath)
+				if len(tt.wantTemp) == 0 {
+					if readErr == nil {
+						t.Errorf("%s file = %q, want removed", tempPath, data)
+					}
+				} else if string(data) != tt.wantTemp {
+					t.Errorf("%s file = %q, want %q", tempPath, data, tt.wantTemp)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(destPath)
+			if err != nil {
+				t.Fatalf("fail to read %s file: %v", destPath, err)
+			}
+			if string(data) != content || size != int64(len(content)) {
+				t.Errorf("downloadFile() = %q (%d bytes), want %q", data, size, content)
+			}
+			if FileExists(tempPath) {
+				t.Errorf("%s file is not renamed", tempPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// DownloadZip 은 여러 파일을 하나의 zip 파일로 묶어 destPath 에 다운로드
+// size 는 묶을 파일들의 전체 크기이며 파일별 최대 전송 시간 계산에 사용
+func (client *SynologyClient) DownloadZip(ctx context.Context, paths []string, size int64, destPath string) (int64, error) {
+	// 전송 감시
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watcher := watchTransfer(client.ConnInfo.Transfer, destPath, size, cancel)
+	defer watcher.Stop()
+
+	var resp *http.Response
+	err := client.withSession(ctx, func(sid string) error {
+		var err error
+		resp, err = client.requestZipDownload(ctx, sid, paths)
+		return err
+	})
+	if err != nil {
+		return 0, watcher.Err(err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s download request: %v", destPath, err)
+		}
+	}()
+
+	// zip 은 이어받을 수 없으므로 항상 처음부터 받음
+	out, err := os.Create(destPath)
+	if err != nil {
+		return 0, errors.Wrapf(err, "fail to create %s file", destPath)
+	}
+	defer func() {
+		if err := out.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			log.Printf("fail to close %s file: %v", destPath, err)
+		}
+	}()
+
+	written, err := io.Copy(out, watcher.Reader(resp.Body))
+	if err != nil {
+		return 0, watcher.Err(errors.Wrapf(err, "fail to copy %s file", destPath))
+	}
+	if err := out.Close(); err != nil {
+		return 0, errors.Wrapf(err, "fail to close %s file", destPath)
+	}
+
+	return written, nil
+}
+
+func (client *SynologyClient) requestZipDownload(ctx context.Context, sid string, paths []string) (*http.Response, error) {
+	// FileStation.Download API 호출 (여러 경로를 지정하면 zip 으로 묶어서 응답)
+	cgiPath, downloadInfo, err := client.apiValues("SYNO.FileStation.Download", "download")
+	if err != nil {
+		return nil, err
+	}
+	pathParam, err := json.Marshal(paths)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to marshal download paths")
+	}
+	downloadInfo.Set("path", string(pathParam))
+	downloadInfo.Set("mode", "download")
+	downloadInfo.Set("_sid", sid)
+
+	synoURL := client.apiURL(cgiPath, downloadInfo)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get %s url", synoURL)
+	}
+
+	return checkDownloadResponse("SYNO.FileStation.Download", synoURL, resp)
+}
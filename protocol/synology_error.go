@@ -0,0 +1,251 @@
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+type ErrorClass int
+
+const (
+	Permanent      ErrorClass = iota // 다시 시도해도 실패
+	Retryable                        // 잠시 후 다시 시도
+	SessionExpired                   // 다시 로그인 후 시도
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case SessionExpired:
+		return "session expired"
+	default:
+		return "permanent"
+	}
+}
+
+type ErrorResponse struct {
+	Code   int `json:"code"`
+	Errors []struct {
+		Code int    `json:"code"`
+		Path string `json:"path"`
+	} `json:"errors,omitempty"`
+}
+
+type SynologyError struct {
+	API    string
+	Code   int
+	Errors []SynologyPathError
+}
+
+type SynologyPathError struct {
+	Code int
+	Path string
+}
+
+type errorInfo struct {
+	message string
+	class   ErrorClass
+}
+
+// 모든 API 공통 에러 코드
+var commonErrors = map[int]errorInfo{
+	100: {"unknown error", Retryable},
+	101: {"no parameter of api, method or version", Permanent},
+	102: {"the requested api does not exist", Permanent},
+	103: {"the requested method does not exist", Permanent},
+	104: {"the requested version does not support the functionality", Permanent},
+	105: {"the logged in session does not have permission", Permanent},
+	106: {"session timeout", SessionExpired},
+	107: {"session interrupted by duplicate login", SessionExpired},
+	108: {"failed to upload the file", Retryable},
+	109: {"the network connection is unstable or the system is busy", Retryable},
+	110: {"the network connection is unstable or the system is busy", Retryable},
+	111: {"the network connection is unstable or the system is busy", Retryable},
+	114: {"lost parameters for this api", Permanent},
+	115: {"not allowed to upload a file", Permanent},
+	116: {"not allowed to perform for a demo site", Permanent},
+	117: {"the network connection is unstable or the system is busy", Retryable},
+	118: {"the network connection is unstable or the system is busy", Retryable},
+	119: {"invalid session", SessionExpired},
+	150: {"request source ip does not match the login ip", Permanent},
+}
+
+// SYNO.API.Auth 에러 코드
+var authErrors = map[int]errorInfo{
+	400: {"no such account or incorrect password", Permanent},
+	401: {"disabled account", Permanent},
+	402: {"denied permission", Permanent},
+	403: {"2-factor authentication code required", Permanent},
+	404: {"failed to authenticate 2-factor authentication code", Permanent},
+	406: {"enforce to authenticate with 2-factor authentication code", Permanent},
+	407: {"blocked ip source", Permanent},
+	408: {"expired password cannot change", Permanent},
+	409: {"expired password", Permanent},
+	410: {"password must be changed", Permanent},
+}
+
+// SYNO.FileStation.*, SYNO.FolderSharing.* 공통 에러 코드
+var fileStationErrors = map[int]errorInfo{
+	400: {"invalid parameter of file operation", Permanent},
+	401: {"unknown error of file operation", Retryable},
+	402: {"system is too busy", Retryable},
+	403: {"invalid user does this file operation", Permanent},
+	404: {"invalid group does this file operation", Permanent},
+	405: {"invalid user and group does this file operation", Permanent},
+	406: {"can't get user/group information from the account server", Retryable},
+	407: {"operation not permitted", Permanent},
+	408: {"no such file or directory", Permanent},
+	409: {"non-supported file system", Permanent},
+	410: {"failed to connect internet-based file system", Retryable},
+	411: {"read-only file system", Permanent},
+	412: {"filename too long in the non-encrypted file system", Permanent},
+	413: {"filename too long in the encrypted file system", Permanent},
+	414: {"file already exists", Permanent},
+	415: {"disk quota exceeded", Permanent},
+	416: {"no space left on device", Permanent},
+	417: {"input/output error", Retryable},
+	418: {"illegal name or path", Permanent},
+	419: {"illegal file name", Permanent},
+	420: {"illegal file name on fat file system", Permanent},
+	421: {"device or resource busy", Retryable},
+	599: {"no such task of the file operation", Permanent},
+}
+
+// SYNO.FileStation.* API 별 에러 코드
+var fileStationAPIErrors = map[string]map[int]errorInfo{
+	"SYNO.FileStation.Delete": {
+		900: {"failed to delete file(s)/folder(s)", Retryable},
+	},
+	"SYNO.FileStation.CopyMove": {
+		1000: {"failed to copy files/folders", Retryable},
+		1001: {"failed to move files/folders", Retryable},
+		1002: {"an error occurred at the destination", Permanent},
+		1003: {"cannot overwrite or skip the existing file because no overwrite parameter is given", Permanent},
+		1004: {"file cannot overwrite a folder with the same name, or folder cannot overwrite a file with the same name", Permanent},
+		1006: {"cannot copy/move file/folder with special characters to a fat32 file system", Permanent},
+		1007: {"cannot copy/move a file bigger than 4g to a fat32 file system", Permanent},
+	},
+	"SYNO.FileStation.Upload": {
+		1800: {"there is no content-length information in the http header or the received size doesn't match", Retryable},
+		1801: {"wait too long, no data can be received from client", Retryable},
+		1802: {"no filename information in the last part of file content", Permanent},
+		1803: {"upload connection is cancelled", Retryable},
+		1804: {"failed to upload oversized file to fat file system", Permanent},
+		1805: {"can't overwrite or skip the existing file, if no overwrite parameter is given", Permanent},
+	},
+}
+
+func newSynologyError(api string, resp *ErrorResponse) *SynologyError {
+	// 에러 정보가 없으면 알 수 없는 에러로 처리
+	if resp == nil {
+		return &SynologyError{API: api, Code: 100}
+	}
+
+	result := &SynologyError{
+		API:  api,
+		Code: resp.Code,
+	}
+	for _, pathErr := range resp.Errors {
+		result.Errors = append(result.Errors, SynologyPathError{
+			Code: pathErr.Code,
+			Path: pathErr.Path,
+		})
+	}
+	return result
+}
+
+func (e *SynologyError) info() errorInfo {
+	if info, ok := commonErrors[e.Code]; ok {
+		return info
+	}
+	if e.API == "SYNO.API.Auth" {
+		if info, ok := authErrors[e.Code]; ok {
+			return info
+		}
+	} else if strings.HasPrefix(e.API, "SYNO.FileStation.") || strings.HasPrefix(e.API, "SYNO.FolderSharing.") {
+		if info, ok := fileStationAPIErrors[e.API][e.Code]; ok {
+			return info
+		}
+		if info, ok := fileStationErrors[e.Code]; ok {
+			return info
+		}
+	}
+	return errorInfo{"undocumented error", Permanent}
+}
+
+func (e *SynologyError) Message() string {
+	return e.info().message
+}
+
+func (e *SynologyError) Class() ErrorClass {
+	return e.info().class
+}
+
+func (e *SynologyError) Retryable() bool {
+	return e.Class() == Retryable
+}
+
+func (e *SynologyError) SessionExpired() bool {
+	return e.Class() == SessionExpired
+}
+
+func (e *SynologyError) Error() string {
+	msg := fmt.Sprintf("%s error %d: %s (%s)", e.API, e.Code, e.Message(), e.Class())
+	for _, pathErr := range e.Errors {
+		msg += fmt.Sprintf(", %s: %d", pathErr.Path, pathErr.Code)
+	}
+	return msg
+}
+
+// IsRetryable 은 잠시 후 다시 시도하면 성공할 수 있는 에러인지 확인
+func IsRetryable(ctx context.Context, err error) bool {
+	// 호출한 쪽이 취소했거나 제한 시간이 지났으면 다시 시도하지 않음
+	// http client 의 응답 헤더 및 연결 제한 시간은 DeadlineExceeded 이지만 아래에서 timeout 으로 재시도
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var synoErr *SynologyError
+	if errors.As(err, &synoErr) {
+		return synoErr.Class() != Permanent
+	}
+
+	var retryableErr interface{ Retryable() bool }
+	if errors.As(err, &retryableErr) {
+		return retryableErr.Retryable()
+	}
+
+	// 인증서 및 fingerprint 불일치는 다시 시도해도 같으므로 재시도하지 않음
+	var fingerprintErr *FingerprintMismatchError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &fingerprintErr) || errors.As(err, &certErr) || errors.As(err, &recordErr) ||
+		errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+
+	// 없는 호스트는 재시도하지 않고 DNS 서버 문제만 재시도
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	// 제한 시간 초과, 연결이 끊기거나 거부된 경우 및 전송 중 끊긴 경우
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF)
+}
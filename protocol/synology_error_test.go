@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// responseHeaderTimeoutError 는 응답 헤더를 기다리다 제한 시간이 지난 실제 http client 에러를 반환
+func responseHeaderTimeoutError(t *testing.T) error {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	httpClient, err := newHTTPClient(&ConnectionInfo{Timeout: &TimeoutInfo{ResponseHeader: 50 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("fail to make http client: %v", err)
+	}
+	resp, err := getWithContext(context.Background(), httpClient, server.URL)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("request did not time out")
+	}
+	return errors.Wrap(err, "fail to get url")
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		canceled bool // 호출한 쪽의 context 가 끝난 상태
+		want     bool
+	}{
+		{name: "response header timeout", err: responseHeaderTimeoutError(t), want: true},
+		{name: "dial timeout", err: &url.Error{Op: "Get", URL: "http://nas", Err: &net.OpError{Op: "dial", Err: context.DeadlineExceeded}}, want: true},
+		{name: "caller deadline", err: errors.Wrap(context.DeadlineExceeded, "fail to get url"), canceled: true, want: false},
+		{name: "caller canceled", err: errors.Wrap(context.Canceled, "fail to get url"), canceled: true, want: false},
+		{name: "canceled", err: &url.Error{Op: "Get", URL: "http://nas", Err: context.Canceled}, want: false},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, want: true},
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "http://nas", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, want: true},
+		{name: "unexpected eof", err: errors.Wrap(io.ErrUnexpectedEOF, "fail to copy file"), want: true},
+		{name: "dns not found", err: &url.Error{Op: "Get", URL: "http://nas", Err: &net.DNSError{Err: "no such host", Name: "nas", IsNotFound: true}}, want: false},
+		{name: "dns timeout", err: &url.Error{Op: "Get", URL: "http://nas", Err: &net.DNSError{Err: "i/o timeout", Name: "nas", IsTimeout: true}}, want: true},
+		{name: "unknown authority", err: &url.Error{Op: "Get", URL: "https://nas", Err: x509.UnknownAuthorityError{}}, want: false},
+		{name: "fingerprint mismatch", err: &url.Error{Op: "Get", URL: "https://nas", Err: &FingerprintMismatchError{Expected: "aa", Actual: "bb"}}, want: false},
+		{name: "session expired", err: newSynologyError("SYNO.FileStation.List", &ErrorResponse{Code: 119}), want: true},
+		{name: "system busy", err: newSynologyError("SYNO.FileStation.List", &ErrorResponse{Code: 117}), want: true},
+		{name: "permission denied", err: newSynologyError("SYNO.FileStation.List", &ErrorResponse{Code: 105}), want: false},
+		{name: "transfer stalled", err: &TransferError{Path: "/photo/a.jpg", Reason: "stalled", Timeout: time.Second}, want: true},
+		{name: "size mismatch", err: &SizeMismatchError{Path: "/photo/a.jpg", Expected: 2, Actual: 1}, want: true},
+		{name: "other", err: errors.New("unknown error"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+
+			if got := IsRetryable(ctx, tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
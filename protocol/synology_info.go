@@ -0,0 +1,133 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type APIInfo struct {
+	Path       string `json:"path"`
+	MinVersion int    `json:"minVersion"`
+	MaxVersion int    `json:"maxVersion"`
+	Version    int    `json:"-"` // 실제로 사용할 버전
+}
+
+type supportedAPI struct {
+	MaxVersion int  // 클라이언트가 구현한 최대 버전
+	Required   bool // 없으면 클라이언트 생성 실패
+}
+
+// 클라이언트가 사용하는 API 목록
+var supportedAPIs = map[string]supportedAPI{
+	"SYNO.API.Auth":             {MaxVersion: 6, Required: true},
+	"SYNO.FileStation.List":     {MaxVersion: 2, Required: true},
+	"SYNO.FileStation.Download": {MaxVersion: 2, Required: true},
+	"SYNO.FileStation.MD5":      {MaxVersion: 2},
+	"SYNO.FileStation.Search":   {MaxVersion: 2},
+	"SYNO.FileStation.Upload":   {MaxVersion: 2},
+
+	"SYNO.FileStation.CopyMove":     {MaxVersion: 3},
+	"SYNO.FileStation.Delete":       {MaxVersion: 2},
+	"SYNO.FileStation.CreateFolder": {MaxVersion: 2},
+
+	"SYNO.Foto.Browse.Album":     {MaxVersion: 1},
+	"SYNO.Foto.Browse.Item":      {MaxVersion: 1},
+	"SYNO.Foto.Download":         {MaxVersion: 1},
+	"SYNO.FotoTeam.Browse.Album": {MaxVersion: 1},
+	"SYNO.FotoTeam.Browse.Item":  {MaxVersion: 1},
+	"SYNO.FotoTeam.Download":     {MaxVersion: 1},
+}
+
+// queryAPIInfo 는 SYNO.API.Info 로 각 API 의 CGI 경로와 사용할 버전을 조회
+func (client *SynologyClient) queryAPIInfo(ctx context.Context) error {
+	names := make([]string, 0, len(supportedAPIs))
+	for name := range supportedAPIs {
+		names = append(names, name)
+	}
+
+	// SYNO.API.Info 는 항상 query.cgi 버전 1 을 지원
+	infoValues := url.Values{}
+	infoValues.Set("api", "SYNO.API.Info")
+	infoValues.Set("version", "1")
+	infoValues.Set("method", "query")
+	infoValues.Set("query", strings.Join(names, ","))
+
+	synoURL := client.apiURL("query.cgi", infoValues)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
+	if err != nil {
+		return errors.Wrapf(err, "fail to get %s url", synoURL)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+	}()
+
+	// API 응답 해석
+	var infoResponse struct {
+		Data    map[string]*APIInfo `json:"data"`
+		Success bool                `json:"success"`
+		Error   *ErrorResponse      `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&infoResponse); err != nil {
+		return fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+	}
+	if !infoResponse.Success {
+		return newSynologyError("SYNO.API.Info", infoResponse.Error)
+	}
+
+	// 서버와 클라이언트가 함께 지원하는 가장 높은 버전 선택
+	client.apis = make(map[string]*APIInfo)
+	for name, supported := range supportedAPIs {
+		info, ok := infoResponse.Data[name]
+		if ok {
+			info.Version = supported.MaxVersion
+			if info.MaxVersion < info.Version {
+				info.Version = info.MaxVersion
+			}
+			ok = info.Version >= info.MinVersion && info.Version > 0
+		}
+
+		if !ok {
+			if supported.Required {
+				return fmt.Errorf("required synology api %s (version <= %d) is not available on this dsm", name, supported.MaxVersion)
+			}
+			log.Printf("optional synology api %s is not available on this dsm", name)
+			continue
+		}
+		client.apis[name] = info
+	}
+
+	return nil
+}
+
+// API 는 사용할 API 정보를 반환
+func (client *SynologyClient) API(name string) (*APIInfo, error) {
+	info, ok := client.apis[name]
+	if !ok {
+		return nil, fmt.Errorf("synology api %s is not available on this dsm", name)
+	}
+	return info, nil
+}
+
+// apiValues 는 API 요청 기본 파라미터와 CGI 경로를 반환
+func (client *SynologyClient) apiValues(name, method string) (string, url.Values, error) {
+	info, err := client.API(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	values := url.Values{}
+	values.Set("api", name)
+	values.Set("version", strconv.Itoa(info.Version))
+	values.Set("method", method)
+
+	return info.Path, values, nil
+}
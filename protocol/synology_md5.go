@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultMD5Timeout = 10 * time.Minute
+
+// MD5 는 FileStation 백그라운드 작업으로 원격 파일의 MD5 해시를 계산
+// DSM 작업이 끝나지 않고 멈춰도 전체 주기가 멈추지 않도록 MD5Timeout 이 지나면 작업을 중단
+func (client *SynologyClient) MD5(ctx context.Context, filePath string) (string, error) {
+	timeout := client.MD5Timeout
+	if timeout <= 0 {
+		timeout = defaultMD5Timeout
+	}
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 작업 시작
+	params := url.Values{}
+	params.Set("file_path", filePath)
+
+	var startResponse struct {
+		TaskID string `json:"taskid"`
+	}
+	if err := client.callAPI(ctx, "SYNO.FileStation.MD5", "start", params, &startResponse); err != nil {
+		return "", err
+	}
+
+	// 작업이 끝날 때까지 상태 확인
+	statusParams := url.Values{}
+	statusParams.Set("taskid", strconv.Quote(startResponse.TaskID))
+	for {
+		var statusResponse struct {
+			Finished bool   `json:"finished"`
+			MD5      string `json:"md5"`
+		}
+		if err := client.callAPI(ctx, "SYNO.FileStation.MD5", "status", statusParams, &statusResponse); err != nil {
+			client.stopTask("SYNO.FileStation.MD5", startResponse.TaskID)
+			return "", err
+		}
+		if statusResponse.Finished {
+			return statusResponse.MD5, nil
+		}
+
+		if err := SleepContext(ctx, taskPollInterval); err != nil {
+			client.stopTask("SYNO.FileStation.MD5", startResponse.TaskID)
+			if parent.Err() == nil {
+				return "", errors.Wrapf(err, "md5 of %s did not finish in %s", filePath, timeout)
+			}
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type PhotoSpace string
+
+const (
+	PersonalSpace = PhotoSpace("personal") // 개인 공간 (SYNO.Foto)
+	SharedSpace   = PhotoSpace("shared")   // 공유 공간 (SYNO.FotoTeam)
+)
+
+// api 는 공간별 Synology Photos API 이름을 반환
+func (space PhotoSpace) api(name string) string {
+	if space == SharedSpace {
+		return "SYNO.FotoTeam." + name
+	}
+	return "SYNO.Foto." + name
+}
+
+type Album struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	ItemCount int        `json:"item_count"`
+	Space     PhotoSpace `json:"-"`
+}
+
+type PhotoItem struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+	Filesize uint64 `json:"filesize"`
+	Time     int64  `json:"time"` // 촬영 시간 (Unix time)
+	Type     string `json:"type"`
+}
+
+// PhotoSpaceAvailable 은 공간의 앨범 조회와 다운로드 API 를 사용할 수 있는지 확인
+func (client *SynologyClient) PhotoSpaceAvailable(space PhotoSpace) error {
+	for _, name := range []string{"Browse.Album", "Browse.Item", "Download"} {
+		if _, err := client.API(space.api(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Albums 는 공간의 모든 앨범을 반환
+func (client *SynologyClient) Albums(ctx context.Context, space PhotoSpace) ([]*Album, error) {
+	var albums []*Album
+	err := client.forEachPhotoPage(space.api("Browse.Album"), url.Values{}, func(params url.Values) (int, error) {
+		var listResponse struct {
+			List []*Album `json:"list"`
+		}
+		if err := client.callAPI(ctx, space.api("Browse.Album"), "list", params, &listResponse); err != nil {
+			return 0, err
+		}
+		for _, album := range listResponse.List {
+			album.Space = space
+		}
+		albums = append(albums, listResponse.List...)
+		return len(listResponse.List), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+// AlbumItems 는 앨범에 포함된 항목마다 fn 을 호출
+func (client *SynologyClient) AlbumItems(ctx context.Context, album *Album, fn func(item *PhotoItem) error) error {
+	api := album.Space.api("Browse.Item")
+	params := url.Values{}
+	params.Set("album_id", strconv.Itoa(album.ID))
+
+	return client.forEachPhotoPage(api, params, func(params url.Values) (int, error) {
+		var listResponse struct {
+			List []*PhotoItem `json:"list"`
+		}
+		if err := client.callAPI(ctx, api, "list", params, &listResponse); err != nil {
+			return 0, err
+		}
+		for _, item := range listResponse.List {
+			if err := fn(item); err != nil {
+				return 0, err
+			}
+		}
+		return len(listResponse.List), nil
+	})
+}
+
+// forEachPhotoPage 는 마지막 페이지까지 offset 을 늘려가며 request 를 호출
+// Synology Photos 목록 API 는 전체 개수를 주지 않으므로 페이지가 가득 차지 않으면 종료
+func (client *SynologyClient) forEachPhotoPage(api string, params url.Values, request func(params url.Values) (int, error)) error {
+	pageSize := client.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	offset := 0
+	for {
+		pageParams := url.Values{}
+		for key := range params {
+			pageParams.Set(key, params.Get(key))
+		}
+		pageParams.Set("offset", strconv.Itoa(offset))
+		pageParams.Set("limit", strconv.Itoa(pageSize))
+
+		count, err := request(pageParams)
+		if err != nil {
+			return errors.Wrapf(err, "fail to list %s", api)
+		}
+		offset += count
+		if count < pageSize {
+			return nil
+		}
+	}
+}
+
+// DownloadPhoto 는 항목의 원본 파일을 destPath 에 다운로드
+func (client *SynologyClient) DownloadPhoto(ctx context.Context, space PhotoSpace, item *PhotoItem, destPath string) (string, int64, error) {
+	file := &File{
+		Name: item.Filename,
+		Path: fmt.Sprintf("%s/%d/%s", space, item.ID, item.Filename),
+	}
+	file.Additional.Size = item.Filesize
+
+	return downloadFile(ctx, client.ConnInfo.Transfer, file, destPath, func(ctx context.Context, offset int64) (*http.Response, error) {
+		var resp *http.Response
+		err := client.withSession(ctx, func(sid string) error {
+			var err error
+			resp, err = client.requestPhotoDownload(ctx, sid, space, item.ID, offset)
+			return err
+		})
+		return resp, err
+	})
+}
+
+func (client *SynologyClient) requestPhotoDownload(ctx context.Context, sid string, space PhotoSpace, itemID int, offset int64) (*http.Response, error) {
+	// Foto.Download API 호출
+	api := space.api("Download")
+	cgiPath, downloadInfo, err := client.apiValues(api, "download")
+	if err != nil {
+		return nil, err
+	}
+	downloadInfo.Set("unit_id", fmt.Sprintf("[%d]", itemID))
+	downloadInfo.Set("force_download", "true")
+	downloadInfo.Set("_sid", sid)
+
+	synoURL := client.apiURL(cgiPath, downloadInfo)
+	return requestRange(ctx, client.httpClient, api, synoURL, offset)
+}
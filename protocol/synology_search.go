@@ -0,0 +1,104 @@
+package protocol
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type SearchFilter struct {
+	Pattern    string   // 파일 이름 패턴 (glob)
+	Extension  []string // 파일 확장자
+	SizeFrom   uint64   // 최소 파일 크기 (Byte)
+	SizeTo     uint64   // 최대 파일 크기 (Byte)
+	MtimeFrom  int64    // 이 시간 이후에 수정된 파일 (Unix time)
+	CrtimeFrom int64    // 이 시간 이후에 생성된 파일 (Unix time)
+}
+
+// Search 는 FileStation 검색 작업으로 조건에 맞는 파일을 찾아 항목마다 fn 을 호출
+func (client *SynologyClient) Search(ctx context.Context, folderPath string, filter *SearchFilter, fn func(file *File) error) error {
+	// 검색 작업 시작
+	params := url.Values{}
+	params.Set("folder_path", folderPath)
+	params.Set("recursive", "true")
+	params.Set("filetype", "file")
+	if filter != nil {
+		if len(filter.Pattern) != 0 {
+			params.Set("pattern", filter.Pattern)
+		}
+		if len(filter.Extension) != 0 {
+			params.Set("extension", strings.Join(filter.Extension, ","))
+		}
+		if filter.SizeFrom != 0 {
+			params.Set("size_from", strconv.FormatUint(filter.SizeFrom, 10))
+		}
+		if filter.SizeTo != 0 {
+			params.Set("size_to", strconv.FormatUint(filter.SizeTo, 10))
+		}
+		if filter.MtimeFrom != 0 {
+			params.Set("mtime_from", strconv.FormatInt(filter.MtimeFrom, 10))
+		}
+		if filter.CrtimeFrom != 0 {
+			params.Set("crtime_from", strconv.FormatInt(filter.CrtimeFrom, 10))
+		}
+	}
+
+	var startResponse struct {
+		TaskID string `json:"taskid"`
+	}
+	if err := client.callAPI(ctx, "SYNO.FileStation.Search", "start", params, &startResponse); err != nil {
+		return err
+	}
+	defer client.cleanSearch(startResponse.TaskID)
+
+	pageSize := client.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	// 검색이 끝날 때까지 결과를 페이지 단위로 조회
+	offset := 0
+	for {
+		listParams := url.Values{}
+		listParams.Set("taskid", strconv.Quote(startResponse.TaskID))
+		listParams.Set("offset", strconv.Itoa(offset))
+		listParams.Set("limit", strconv.Itoa(pageSize))
+		listParams.Set("filetype", "file")
+		listParams.Set("additional", "size,time")
+
+		var listResponse struct {
+			Finished bool    `json:"finished"`
+			Files    []*File `json:"files"`
+			Offset   int     `json:"offset"`
+			Total    int     `json:"total"`
+		}
+		if err := client.callAPI(ctx, "SYNO.FileStation.Search", "list", listParams, &listResponse); err != nil {
+			return err
+		}
+
+		for _, file := range listResponse.Files {
+			if err := fn(file); err != nil {
+				return err
+			}
+		}
+		offset += len(listResponse.Files)
+
+		// total 은 filetype 으로 거르기 전 개수일 수 있으므로 끝난 후 빈 페이지가 오면 종료
+		if listResponse.Finished && (offset >= listResponse.Total || len(listResponse.Files) == 0) {
+			return nil
+		}
+		// 아직 찾은 결과가 없으면 잠시 대기
+		if len(listResponse.Files) == 0 {
+			if err := SleepContext(ctx, taskPollInterval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// cleanSearch 는 검색 작업을 중지하고 임시 결과를 삭제
+func (client *SynologyClient) cleanSearch(taskID string) {
+	client.stopTask("SYNO.FileStation.Search", taskID)
+	client.cleanTask("SYNO.FileStation.Search", taskID)
+}
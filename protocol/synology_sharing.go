@@ -0,0 +1,371 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type SharingInfo struct {
+	URL      string         // 공유 링크 URL (https://nas:5001/sharing/AbCdEfGh 또는 https://gofile.me/AbCdE/AbCdEfGh)
+	Password string         // 공유 링크 비밀번호
+	TLS      *TLSInfo       // https 인증서 확인 방법
+	Timeout  *TimeoutInfo   // 요청 제한 시간
+	Transfer *TransferLimit // 파일 전송 제한 시간
+	Root     string         // 공유 폴더 대신 사용할 경로
+}
+
+// SharingClient 는 계정 없이 공유 링크로 파일을 조회하고 다운로드
+type SharingClient struct {
+	Info     *SharingInfo
+	PageSize int
+
+	httpClient *http.Client
+	baseURL    string
+	sharingID  string
+	sid        string
+	mu         sync.RWMutex
+}
+
+// shortSharingHost 는 QuickConnect 로 공유한 링크의 단축 주소 (https://gofile.me/AbCdE/AbCdEfGh)
+const shortSharingHost = "gofile.me"
+
+// 단축 링크에서 공유 링크를 찾을 때 따라갈 최대 redirect 횟수
+const maxSharingRedirects = 10
+
+// ParseSharingURL 은 공유 링크 URL 에서 NAS 주소(reverse proxy 경로 포함)와 공유 ID 를 찾아 반환
+// 단축 링크는 NAS 주소를 알 수 없으므로 링크를 그대로 반환하며 NewSharingClient 에서 redirect 를 따라가 찾음
+func ParseSharingURL(sharingURL string) (*url.URL, string, error) {
+	u, err := url.Parse(sharingURL)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "fail to parse %s sharing url", sharingURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || len(u.Host) == 0 {
+		return nil, "", fmt.Errorf("invalid sharing url: %s", sharingURL)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if isShortSharingURL(u) && len(parts) == 2 && len(parts[1]) != 0 {
+		return &url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}, parts[1], nil
+	}
+	if len(parts) < 2 || parts[len(parts)-2] != "sharing" || len(parts[len(parts)-1]) == 0 {
+		return nil, "", fmt.Errorf("sharing id not found in sharing url: %s", sharingURL)
+	}
+
+	base := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	if prefix := parts[:len(parts)-2]; len(prefix) != 0 {
+		base.Path = "/" + strings.Join(prefix, "/")
+	}
+	return base, parts[len(parts)-1], nil
+}
+
+func isShortSharingURL(u *url.URL) bool {
+	return strings.EqualFold(u.Hostname(), shortSharingHost)
+}
+
+// resolveSharingURL 은 단축 링크의 redirect 를 따라가 NAS 의 공유 링크 URL 을 반환
+// 단축 링크 서버는 공인 인증서를 사용하므로 NAS 에 연결하기 전에 멈춤
+func resolveSharingURL(ctx context.Context, timeout *TimeoutInfo, shortURL string) (string, error) {
+	httpClient, err := newHTTPClient(&ConnectionInfo{Timeout: timeout})
+	if err != nil {
+		return "", errors.Wrap(err, "fail to make http client")
+	}
+
+	var sharingURL string
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if u, _, err := ParseSharingURL(req.URL.String()); err == nil && !isShortSharingURL(u) {
+			sharingURL = req.URL.String()
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxSharingRedirects {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		return nil
+	}
+
+	resp, err := getWithContext(ctx, httpClient, shortURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to get %s url", shortURL)
+	}
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("fail to close %s request: %v", shortURL, err)
+	}
+	if len(sharingURL) == 0 {
+		return "", fmt.Errorf("%s did not redirect to a sharing link (%s)", shortURL, resp.Status)
+	}
+
+	log.Printf("resolve %s sharing link to %s", shortURL, sharingURL)
+	return sharingURL, nil
+}
+
+func NewSharingClient(ctx context.Context, info *SharingInfo) (*SharingClient, error) {
+	u, sharingID, err := ParseSharingURL(info.URL)
+	if err != nil {
+		return nil, err
+	}
+	// 단축 링크는 NAS 주소를 찾아 사용
+	if isShortSharingURL(u) {
+		sharingURL, err := resolveSharingURL(ctx, info.Timeout, u.String())
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to resolve short sharing link")
+		}
+		if u, sharingID, err = ParseSharingURL(sharingURL); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient, err := newHTTPClient(&ConnectionInfo{Scheme: u.Scheme, TLS: info.TLS, Timeout: info.Timeout})
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make http client")
+	}
+	// 공유 세션은 쿠키로 전달
+	httpClient.Jar, err = cookiejar.New(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make cookie jar")
+	}
+
+	client := &SharingClient{
+		Info:       info,
+		httpClient: httpClient,
+		baseURL:    u.String(),
+		sharingID:  sharingID,
+	}
+	if client.sid, err = client.login(ctx); err != nil {
+		return nil, errors.Wrap(err, "fail to login sharing link")
+	}
+
+	return client, nil
+}
+
+// login 은 공유 링크 페이지를 열어 세션 쿠키를 받고 비밀번호가 있으면 인증
+func (client *SharingClient) login(ctx context.Context) (string, error) {
+	sharingURL := fmt.Sprintf("%s/sharing/%s", client.baseURL, client.sharingID)
+	resp, err := getWithContext(ctx, client.httpClient, sharingURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to get %s url", sharingURL)
+	}
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("fail to close %s request: %v", sharingURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fail to open %s sharing link: %s", sharingURL, resp.Status)
+	}
+
+	if len(client.Info.Password) == 0 {
+		return "", nil
+	}
+
+	// SYNO.Core.Sharing.Login API 호출
+	loginInfo := url.Values{}
+	loginInfo.Set("api", "SYNO.Core.Sharing.Login")
+	loginInfo.Set("version", "1")
+	loginInfo.Set("method", "login")
+	loginInfo.Set("sharing_id", client.sharingID)
+	loginInfo.Set("password", client.Info.Password)
+
+	synoURL := client.baseURL + "/webapi/entry.cgi"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, synoURL, strings.NewReader(loginInfo.Encode()))
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to make %s request", synoURL)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = client.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to post %s url", synoURL)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+	}()
+
+	// API 응답 해석
+	var loginResponse struct {
+		Data struct {
+			SharingSID string `json:"sharing_sid"`
+		} `json:"data"`
+		Success bool           `json:"success"`
+		Error   *ErrorResponse `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&loginResponse); err != nil {
+		return "", fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+	}
+	if !loginResponse.Success {
+		return "", newSynologyError("SYNO.Core.Sharing.Login", loginResponse.Error)
+	}
+
+	return loginResponse.Data.SharingSID, nil
+}
+
+// withSession 은 공유 세션이 만료되면 다시 인증한 후 한 번 더 요청
+func (client *SharingClient) withSession(ctx context.Context, request func(sid string) error) error {
+	client.mu.RLock()
+	sid := client.sid
+	client.mu.RUnlock()
+
+	err := request(sid)
+	var synoErr *SynologyError
+	if err == nil || !errors.As(err, &synoErr) || !synoErr.SessionExpired() {
+		return err
+	}
+
+	client.mu.Lock()
+	if client.sid == sid {
+		log.Printf("sharing session expired, login again")
+		newSID, err := client.login(ctx)
+		if err != nil {
+			client.mu.Unlock()
+			return errors.Wrap(err, "fail to login sharing link")
+		}
+		client.sid = newSID
+	}
+	sid = client.sid
+	client.mu.Unlock()
+
+	return request(sid)
+}
+
+// apiURL 은 공유 링크 API 요청 URL 을 반환
+func (client *SharingClient) apiURL(cgi string, params url.Values, sid string) string {
+	params.Set("_sharing_id", strconv.Quote(client.sharingID))
+	if len(sid) != 0 {
+		params.Set("sharing_sid", sid)
+	}
+	return fmt.Sprintf("%s/%s?%s", client.baseURL, cgi, params.Encode())
+}
+
+// sharingPath 는 Root 기준 경로를 공유 폴더 기준 경로로 변환
+func (client *SharingClient) sharingPath(filePath string) string {
+	relPath, _ := strings.CutPrefix(filePath, client.Info.Root)
+	return path.Join("/", relPath)
+}
+
+func (client *SharingClient) GetFileList(ctx context.Context, folderPath string) (*FileListResponse, error) {
+	pageSize := client.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	// 모든 페이지를 하나의 응답으로 병합
+	fileListResponse := &FileListResponse{Success: true}
+	offset := 0
+	for {
+		var page *FileListResponse
+		err := client.withSession(ctx, func(sid string) error {
+			var err error
+			page, err = client.requestFileListPage(ctx, sid, client.sharingPath(folderPath), offset, pageSize)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// 공유 폴더 기준 경로를 Root 기준 경로로 변환
+		for _, file := range page.Data.Files {
+			file.Path = path.Join(client.Info.Root, file.Path)
+		}
+		fileListResponse.Data.Files = append(fileListResponse.Data.Files, page.Data.Files...)
+
+		// 마지막 페이지 확인
+		offset += len(page.Data.Files)
+		if len(page.Data.Files) == 0 || offset >= page.Data.Total {
+			break
+		}
+	}
+	fileListResponse.Data.Total = len(fileListResponse.Data.Files)
+
+	return fileListResponse, nil
+}
+
+func (client *SharingClient) requestFileListPage(ctx context.Context, sid, folderPath string, offset, limit int) (*FileListResponse, error) {
+	// FolderSharing.List API 호출
+	listInfo := url.Values{}
+	listInfo.Set("api", "SYNO.FolderSharing.List")
+	listInfo.Set("version", "2")
+	listInfo.Set("method", "list")
+	listInfo.Set("action", "enum")
+	listInfo.Set("folder_path", strconv.Quote(folderPath))
+	listInfo.Set("offset", strconv.Itoa(offset))
+	listInfo.Set("limit", strconv.Itoa(limit))
+	listInfo.Set("filetype", "all")
+	listInfo.Set("additional", `["size","time"]`)
+
+	synoURL := client.apiURL("webapi/entry.cgi", listInfo, sid)
+	resp, err := getWithContext(ctx, client.httpClient, synoURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get %s url", synoURL)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+	}()
+
+	// API 응답 해석
+	fileListResponse := &FileListResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(fileListResponse); err != nil {
+		return nil, fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+	}
+	if !fileListResponse.Success {
+		return nil, newSynologyError("SYNO.FolderSharing.List", fileListResponse.Error)
+	}
+
+	return fileListResponse, nil
+}
+
+func (client *SharingClient) DownloadFile(ctx context.Context, file *File, destPath string) (string, int64, error) {
+	return downloadFile(ctx, client.Info.Transfer, file, destPath, func(ctx context.Context, offset int64) (*http.Response, error) {
+		var resp *http.Response
+		err := client.withSession(ctx, func(sid string) error {
+			var err error
+			resp, err = client.requestDownload(ctx, sid, client.sharingPath(file.Path), offset)
+			return err
+		})
+		return resp, err
+	})
+}
+
+func (client *SharingClient) requestDownload(ctx context.Context, sid, filePath string, offset int64) (*http.Response, error) {
+	// FolderSharing.Download API 호출
+	pathParam, err := json.Marshal([]string{filePath})
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to marshal download path")
+	}
+	downloadInfo := url.Values{}
+	downloadInfo.Set("api", "SYNO.FolderSharing.Download")
+	downloadInfo.Set("version", "2")
+	downloadInfo.Set("method", "download")
+	downloadInfo.Set("mode", "download")
+	downloadInfo.Set("stdhtml", "false")
+	downloadInfo.Set("dlname", strconv.Quote(path.Base(filePath)))
+	downloadInfo.Set("path", string(pathParam))
+
+	cgi := "fsdownload/webapi/file_download.cgi/" + url.PathEscape(path.Base(filePath))
+	synoURL := client.apiURL(cgi, downloadInfo, sid)
+	return requestRange(ctx, client.httpClient, "SYNO.FolderSharing.Download", synoURL, offset)
+}
+
+// MD5 는 공유 링크에서 지원하지 않음
+func (client *SharingClient) MD5(_ context.Context, filePath string) (string, error) {
+	return "", fmt.Errorf("md5 of %s is not available on sharing link", filePath)
+}
+
+// Logout 은 공유 세션 쿠키를 정리
+func (client *SharingClient) Logout(_ context.Context) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.sid = ""
+	client.httpClient.CloseIdleConnections()
+	return nil
+}
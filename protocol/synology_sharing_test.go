@@ -0,0 +1,135 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseSharingURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		sharingURL    string
+		wantBaseURL   string
+		wantSharingID string
+		wantErr       bool
+	}{
+		{name: "https", sharingURL: "https://nas.example.com:5001/sharing/AbCdEf123", wantBaseURL: "https://nas.example.com:5001", wantSharingID: "AbCdEf123"},
+		{name: "http", sharingURL: "http://192.168.0.2:5000/sharing/AbCdEf123", wantBaseURL: "http://192.168.0.2:5000", wantSharingID: "AbCdEf123"},
+		{name: "trailing slash", sharingURL: "https://nas.example.com/sharing/AbCdEf123/", wantBaseURL: "https://nas.example.com", wantSharingID: "AbCdEf123"},
+		{name: "reverse proxy prefix", sharingURL: "https://example.com/nas/sharing/AbCdEf123", wantBaseURL: "https://example.com/nas", wantSharingID: "AbCdEf123"},
+		{name: "nested reverse proxy prefix", sharingURL: "https://example.com/home/nas/sharing/AbCdEf123", wantBaseURL: "https://example.com/home/nas", wantSharingID: "AbCdEf123"},
+		{name: "query", sharingURL: "https://nas.example.com/sharing/AbCdEf123?lang=ko", wantBaseURL: "https://nas.example.com", wantSharingID: "AbCdEf123"},
+		{name: "gofile short link", sharingURL: "https://gofile.me/AbCdE/AbCdEf123", wantBaseURL: "https://gofile.me/AbCdE/AbCdEf123", wantSharingID: "AbCdEf123"},
+		{name: "gofile without sharing id", sharingURL: "https://gofile.me/AbCdE", wantErr: true},
+		{name: "no sharing id", sharingURL: "https://nas.example.com/sharing/", wantErr: true},
+		{name: "no sharing path", sharingURL: "https://nas.example.com/AbCdEf123", wantErr: true},
+		{name: "other scheme", sharingURL: "ftp://nas.example.com/sharing/AbCdEf123", wantErr: true},
+		{name: "no host", sharingURL: "/sharing/AbCdEf123", wantErr: true},
+		{name: "invalid url", sharingURL: "https://nas.example.com:port/sharing/AbCdEf123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, sharingID, err := ParseSharingURL(tt.sharingURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSharingURL(%q) error = %v, wantErr %v", tt.sharingURL, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if u.String() != tt.wantBaseURL {
+				t.Errorf("ParseSharingURL(%q) base url = %q, want %q", tt.sharingURL, u.String(), tt.wantBaseURL)
+			}
+			if sharingID != tt.wantSharingID {
+				t.Errorf("ParseSharingURL(%q) sharing id = %q, want %q", tt.sharingURL, sharingID, tt.wantSharingID)
+			}
+		})
+	}
+}
+
+func TestResolveSharingURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		redirects map[string]string // 요청 경로별 redirect 경로
+		wantPath  string
+		wantErr   bool
+	}{
+		{
+			name:      "redirect to sharing link",
+			redirects: map[string]string{"/AbCdE/AbCdEf123": "/nas/sharing/AbCdEf123"},
+			wantPath:  "/nas/sharing/AbCdEf123",
+		},
+		{
+			name: "redirect through relay",
+			redirects: map[string]string{
+				"/AbCdE/AbCdEf123": "/relay/AbCdEf123",
+				"/relay/AbCdEf123": "/sharing/AbCdEf123",
+			},
+			wantPath: "/sharing/AbCdEf123",
+		},
+		{name: "no redirect", redirects: map[string]string{}, wantErr: true},
+		{
+			name:      "redirect loop",
+			redirects: map[string]string{"/AbCdE/AbCdEf123": "/AbCdE/AbCdEf123"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if target, ok := tt.redirects[r.URL.Path]; ok {
+					http.Redirect(w, r, target, http.StatusFound)
+					return
+				}
+				if strings.Contains(r.URL.Path, "/sharing/") {
+					t.Errorf("resolveSharingURL() requested %s sharing link", r.URL.Path)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			got, err := resolveSharingURL(context.Background(), nil, server.URL+"/AbCdE/AbCdEf123")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSharingURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != server.URL+tt.wantPath {
+				t.Errorf("resolveSharingURL() = %q, want %q", got, server.URL+tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestNewSharingClientPathPrefix(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/nas/sharing/AbCdEf123":
+			w.WriteHeader(http.StatusOK)
+		case "/nas/webapi/entry.cgi":
+			writeTestResponse(w, map[string]string{"sharing_sid": "sharing-sid"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewSharingClient(context.Background(), &SharingInfo{
+		URL:      server.URL + "/nas/sharing/AbCdEf123",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatalf("NewSharingClient() error = %v (requested %v)", err, paths)
+	}
+	if client.sid != "sharing-sid" {
+		t.Errorf("NewSharingClient() sid = %q, want %q", client.sid, "sharing-sid")
+	}
+	if apiURL := client.apiURL("webapi/entry.cgi", url.Values{}, ""); !strings.HasPrefix(apiURL, server.URL+"/nas/webapi/entry.cgi?") {
+		t.Errorf("apiURL() = %q, want %s/nas/webapi/entry.cgi prefix", apiURL, server.URL)
+	}
+}
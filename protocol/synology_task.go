@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+// 백그라운드 작업 상태 확인 주기
+const taskPollInterval = 1 * time.Second
+
+// 작업 중지, 정리 요청 제한 시간
+const taskCleanupTimeout = 30 * time.Second
+
+// stopTask 는 실행 중인 백그라운드 작업을 중지
+// 요청이 취소된 뒤에도 작업을 정리해야 하므로 별도의 context 를 사용
+func (client *SynologyClient) stopTask(api, taskID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskCleanupTimeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("taskid", strconv.Quote(taskID))
+	if err := client.callAPI(ctx, api, "stop", params, nil); err != nil {
+		log.Printf("fail to stop %s %s task: %v", api, taskID, err)
+	}
+}
+
+// cleanTask 는 끝난 백그라운드 작업의 임시 데이터를 삭제
+func (client *SynologyClient) cleanTask(api, taskID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskCleanupTimeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("taskid", strconv.Quote(taskID))
+	if err := client.callAPI(ctx, api, "clean", params, nil); err != nil {
+		log.Printf("fail to clean %s %s task: %v", api, taskID, err)
+	}
+}
+
+// waitTask 는 백그라운드 작업이 끝날 때까지 상태를 확인
+func (client *SynologyClient) waitTask(ctx context.Context, api, taskID string) error {
+	params := url.Values{}
+	params.Set("taskid", strconv.Quote(taskID))
+	for {
+		var statusResponse struct {
+			Finished bool `json:"finished"`
+		}
+		if err := client.callAPI(ctx, api, "status", params, &statusResponse); err != nil {
+			return err
+		}
+		if statusResponse.Finished {
+			return nil
+		}
+
+		if err := SleepContext(ctx, taskPollInterval); err != nil {
+			client.stopTask(api, taskID)
+			return err
+		}
+	}
+}
+
+// Move 는 파일을 destFolderPath 폴더로 이동
+func (client *SynologyClient) Move(ctx context.Context, filePath, destFolderPath string, overwrite bool) error {
+	params := url.Values{}
+	params.Set("path", filePath)
+	params.Set("dest_folder_path", destFolderPath)
+	params.Set("overwrite", strconv.FormatBool(overwrite))
+	params.Set("remove_src", "true")
+
+	var startResponse struct {
+		TaskID string `json:"taskid"`
+	}
+	if err := client.callAPI(ctx, "SYNO.FileStation.CopyMove", "start", params, &startResponse); err != nil {
+		return err
+	}
+	return client.waitTask(ctx, "SYNO.FileStation.CopyMove", startResponse.TaskID)
+}
+
+// Delete 는 파일을 삭제
+func (client *SynologyClient) Delete(ctx context.Context, filePath string) error {
+	params := url.Values{}
+	params.Set("path", filePath)
+	params.Set("recursive", "false")
+
+	var startResponse struct {
+		TaskID string `json:"taskid"`
+	}
+	if err := client.callAPI(ctx, "SYNO.FileStation.Delete", "start", params, &startResponse); err != nil {
+		return err
+	}
+	return client.waitTask(ctx, "SYNO.FileStation.Delete", startResponse.TaskID)
+}
+
+// CreateFolder 는 상위 폴더를 포함해 폴더를 생성
+func (client *SynologyClient) CreateFolder(ctx context.Context, folderPath string) error {
+	params := url.Values{}
+	params.Set("folder_path", path.Dir(folderPath))
+	params.Set("name", path.Base(folderPath))
+	params.Set("force_parent", "true")
+
+	return client.callAPI(ctx, "SYNO.FileStation.CreateFolder", "create", params, nil)
+}
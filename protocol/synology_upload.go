@@ -0,0 +1,134 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type OverwritePolicy string
+
+const (
+	OverwriteError = OverwritePolicy("error")     // 이미 존재하면 실패
+	Overwrite      = OverwritePolicy("overwrite") // 덮어쓰기
+	OverwriteSkip  = OverwritePolicy("skip")      // 건너뛰기
+)
+
+// UploadFile 은 로컬 파일을 FileStation 에 업로드하고 전송한 크기를 반환
+// 같은 이름의 파일이 있어 건너뛰었으면 0 을 반환
+func (client *SynologyClient) UploadFile(ctx context.Context, localFilePath, remoteFilePath string, overwrite OverwritePolicy) (int64, error) {
+	var size int64
+	err := client.withSession(ctx, func(sid string) error {
+		var err error
+		size, err = client.requestUpload(ctx, sid, localFilePath, remoteFilePath, overwrite)
+		return err
+	})
+	return size, err
+}
+
+func (client *SynologyClient) requestUpload(ctx context.Context, sid, localFilePath, remoteFilePath string, overwrite OverwritePolicy) (int64, error) {
+	cgiPath, uploadInfo, err := client.apiValues("SYNO.FileStation.Upload", "upload")
+	if err != nil {
+		return 0, err
+	}
+	uploadInfo.Set("_sid", sid)
+
+	// 파일 열기
+	localFile, err := os.Open(localFilePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "fail to open local file")
+	}
+	defer func() {
+		if err := localFile.Close(); err != nil {
+			log.Printf("fail to close %s file: %v", localFilePath, err)
+		}
+	}()
+	localFileInfo, err := localFile.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "fail to stat local file")
+	}
+
+	// multipart 헤더 생성 (파일 내용은 스트리밍)
+	var header bytes.Buffer
+	writer := multipart.NewWriter(&header)
+	fields := url.Values{}
+	fields.Set("path", path.Dir(remoteFilePath))
+	fields.Set("create_parents", "true")
+	fields.Set("mtime", strconv.FormatInt(localFileInfo.ModTime().UnixMilli(), 10))
+	switch overwrite {
+	case Overwrite:
+		fields.Set("overwrite", "true")
+	case OverwriteSkip:
+		fields.Set("overwrite", "false")
+	}
+	for _, key := range []string{"path", "create_parents", "overwrite", "mtime"} {
+		if value := fields.Get(key); len(value) != 0 {
+			if err := writer.WriteField(key, value); err != nil {
+				return 0, errors.Wrapf(err, "fail to write %s field", key)
+			}
+		}
+	}
+	if _, err := writer.CreateFormFile("file", path.Base(remoteFilePath)); err != nil {
+		return 0, errors.Wrap(err, "fail to create form file")
+	}
+	footer := fmt.Sprintf("\r\n--%s--\r\n", writer.Boundary())
+
+	// FileStation 은 Content-Length 가 필요하므로 전체 크기를 미리 계산
+	body := io.MultiReader(&header, localFile, bytes.NewBufferString(footer))
+	contentLength := int64(header.Len()) + localFileInfo.Size() + int64(len(footer))
+
+	// 전송 감시
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watcher := watchTransfer(client.ConnInfo.Transfer, localFilePath, contentLength, cancel)
+	defer watcher.Stop()
+
+	synoURL := client.apiURL(cgiPath, uploadInfo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, synoURL, watcher.BodyReader(body))
+	if err != nil {
+		return 0, errors.Wrapf(err, "fail to make %s request", synoURL)
+	}
+	req.ContentLength = contentLength
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return 0, watcher.Err(errors.Wrapf(err, "fail to post %s url", synoURL))
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("fail to close %s request: %v", synoURL, err)
+		}
+	}()
+
+	// API 응답 해석
+	var uploadResponse struct {
+		Data struct {
+			Skip bool `json:"blSkip"`
+		} `json:"data"`
+		Success bool           `json:"success"`
+		Error   *ErrorResponse `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&uploadResponse); err != nil {
+		return 0, fmt.Errorf("fail to decode %s response body: %v", synoURL, err)
+	}
+	if !uploadResponse.Success {
+		return 0, newSynologyError("SYNO.FileStation.Upload", uploadResponse.Error)
+	}
+	if uploadResponse.Data.Skip {
+		return 0, nil
+	}
+
+	return localFileInfo.Size(), nil
+}
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type TLSInfo struct {
+	CAFile      string // 추가로 신뢰할 CA 번들(PEM) 경로
+	Fingerprint string // 고정할 서버 인증서 SHA-256 fingerprint
+	Insecure    bool   // 인증서 검증 생략
+}
+
+type TimeoutInfo struct {
+	Connect        time.Duration // 연결 제한 시간
+	ResponseHeader time.Duration // 요청 후 응답 헤더를 받을 때까지 제한 시간
+	Idle           time.Duration // 사용하지 않는 연결 유지 시간
+}
+
+func newHTTPClient(info *ConnectionInfo) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// 제한 시간 설정
+	if timeout := info.Timeout; timeout != nil {
+		if timeout.Connect > 0 {
+			transport.DialContext = (&net.Dialer{
+				Timeout:   timeout.Connect,
+				KeepAlive: 30 * time.Second,
+			}).DialContext
+			transport.TLSHandshakeTimeout = timeout.Connect
+		}
+		transport.ResponseHeaderTimeout = timeout.ResponseHeader
+		if timeout.Idle > 0 {
+			transport.IdleConnTimeout = timeout.Idle
+		}
+	}
+
+	if info.Scheme == "https" {
+		tlsConfig, err := newTLSConfig(info.TLS)
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to make tls config")
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// getWithContext 는 ctx 가 취소되면 중단되는 GET 요청을 보냄
+func getWithContext(ctx context.Context, httpClient *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+func newTLSConfig(info *TLSInfo) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if info == nil {
+		// 시스템 CA 사용
+		return tlsConfig, nil
+	}
+
+	// 인증서 검증 생략
+	if info.Insecure {
+		log.Print("tls certificate verification is disabled")
+		tlsConfig.InsecureSkipVerify = true
+		return tlsConfig, nil
+	}
+
+	// 인증서 fingerprint 고정 (자체 서명 인증서)
+	if len(info.Fingerprint) != 0 {
+		fingerprint, err := ParseFingerprint(info.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		// 체인 검증 대신 서버 인증서의 fingerprint 만 비교
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !strings.EqualFold(hex.EncodeToString(sum[:]), fingerprint) {
+				return &FingerprintMismatchError{Expected: fingerprint, Actual: hex.EncodeToString(sum[:])}
+			}
+			return nil
+		}
+		return tlsConfig, nil
+	}
+
+	// 사용자 CA 번들 추가
+	if len(info.CAFile) != 0 {
+		pem, err := os.ReadFile(info.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to read %s ca file: %v", info.CAFile, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s ca file", info.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// FingerprintMismatchError 는 서버 인증서 fingerprint 가 설정한 값과 다를 때 발생
+type FingerprintMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *FingerprintMismatchError) Error() string {
+	return fmt.Sprintf("certificate fingerprint mismatch (expected: %s, got: %s)", e.Expected, e.Actual)
+}
+
+// ParseFingerprint 는 "SHA256:", 콜론 구분자 등을 제거한 소문자 hex 문자열을 반환
+func ParseFingerprint(fingerprint string) (string, error) {
+	result := strings.TrimSpace(fingerprint)
+	if idx := strings.Index(result, ":"); idx >= 0 && strings.EqualFold(result[:idx], "sha256") {
+		result = result[idx+1:]
+	}
+	result = strings.ToLower(strings.ReplaceAll(result, ":", ""))
+
+	if decoded, err := hex.DecodeString(result); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 fingerprint: %s", fingerprint)
+	}
+	return result, nil
+}
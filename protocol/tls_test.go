@@ -0,0 +1,39 @@
+package protocol
+
+import "testing"
+
+func TestParseFingerprint(t *testing.T) {
+	const hexFingerprint = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	const colonFingerprint = "01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF"
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		want        string
+		wantErr     bool
+	}{
+		{name: "hex", fingerprint: hexFingerprint, want: hexFingerprint},
+		{name: "upper case", fingerprint: "0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF", want: hexFingerprint},
+		{name: "colon separated", fingerprint: colonFingerprint, want: hexFingerprint},
+		{name: "sha256 prefix", fingerprint: "SHA256:" + colonFingerprint, want: hexFingerprint},
+		{name: "lower sha256 prefix", fingerprint: "sha256:" + hexFingerprint, want: hexFingerprint},
+		{name: "surrounding space", fingerprint: "  " + hexFingerprint + "\n", want: hexFingerprint},
+		{name: "empty", fingerprint: "", wantErr: true},
+		{name: "too short", fingerprint: "0123456789abcdef", wantErr: true},
+		{name: "sha1 length", fingerprint: "0123456789abcdef0123456789abcdef01234567", wantErr: true},
+		{name: "not hex", fingerprint: "zz23456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", wantErr: true},
+		{name: "other prefix", fingerprint: "MD5:" + hexFingerprint, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFingerprint(tt.fingerprint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFingerprint(%q) error = %v, wantErr %v", tt.fingerprint, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFingerprint(%q) = %q, want %q", tt.fingerprint, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OTPInfo struct {
+	Secret          string // TOTP secret (base32)
+	DeviceName      string // 신뢰할 수 있는 기기 이름
+	DeviceTokenFile string // 기기 토큰(did) 저장 경로
+}
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+)
+
+// GenerateTOTP 는 RFC 6238 TOTP 코드(HMAC-SHA1, 30초, 6자리)를 생성
+func GenerateTOTP(secret string, t time.Time) (string, error) {
+	// 공백 및 패딩 제거 후 base32 디코딩
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return "", fmt.Errorf("fail to decode otp secret: %v", err)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/totpPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000), nil
+}
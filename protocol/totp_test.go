@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTP(t *testing.T) {
+	// RFC 6238 부록 B 의 SHA1 테스트 벡터 ("12345678901234567890" 의 base32) 중 뒤 6 자리
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		name    string
+		secret  string
+		unix    int64
+		want    string
+		wantErr bool
+	}{
+		{name: "59", secret: secret, unix: 59, want: "287082"},
+		{name: "1111111109", secret: secret, unix: 1111111109, want: "081804"},
+		{name: "1111111111", secret: secret, unix: 1111111111, want: "050471"},
+		{name: "1234567890", secret: secret, unix: 1234567890, want: "005924"},
+		{name: "2000000000", secret: secret, unix: 2000000000, want: "279037"},
+		{name: "20000000000", secret: secret, unix: 20000000000, want: "353130"},
+		{name: "lower case with spaces", secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", unix: 59, want: "287082"},
+		{name: "padding", secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ====", unix: 59, want: "287082"},
+		{name: "invalid secret", secret: "not base32!", unix: 59, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateTOTP(tt.secret, time.Unix(tt.unix, 0))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateTOTP(%q, %d) error = %v, wantErr %v", tt.secret, tt.unix, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GenerateTOTP(%q, %d) = %q, want %q", tt.secret, tt.unix, got, tt.want)
+			}
+		})
+	}
+}
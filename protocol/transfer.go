@@ -0,0 +1,138 @@
+package protocol
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 전송 상태 확인 주기
+const transferCheckInterval = 1 * time.Second
+
+type TransferLimit struct {
+	StallTimeout time.Duration // 이 시간 동안 전송된 데이터가 없으면 중단
+	BaseTimeout  time.Duration // 파일 크기와 관계없이 허용하는 전송 시간
+	MinRate      int64         // 파일별 최대 전송 시간을 계산할 최소 전송 속도 (Byte/s)
+}
+
+// Deadline 은 size 크기의 파일을 전송할 수 있는 최대 시간을 반환하며 제한이 없으면 0 을 반환
+func (limit *TransferLimit) Deadline(size int64) time.Duration {
+	if limit == nil || limit.MinRate <= 0 {
+		return 0
+	}
+	return limit.BaseTimeout + time.Duration(size/limit.MinRate)*time.Second
+}
+
+// TransferError 는 전송이 멈췄거나 파일별 최대 전송 시간을 넘겨 중단했을 때 발생
+type TransferError struct {
+	Path    string
+	Reason  string
+	Timeout time.Duration
+}
+
+func (e *TransferError) Error() string {
+	return fmt.Sprintf("%s transfer %s (timeout: %s)", e.Path, e.Reason, e.Timeout)
+}
+
+// Retryable 은 연결을 새로 맺어 다시 받으면 되므로 항상 true
+func (e *TransferError) Retryable() bool {
+	return true
+}
+
+// transferWatcher 는 전송 진행 상황을 확인하고 멈추거나 너무 오래 걸리면 abort 를 호출
+type transferWatcher struct {
+	last     atomic.Int64
+	err      atomic.Pointer[TransferError]
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func watchTransfer(limit *TransferLimit, path string, size int64, abort func()) *transferWatcher {
+	watcher := &transferWatcher{
+		done: make(chan struct{}),
+	}
+	watcher.last.Store(time.Now().UnixNano())
+
+	var stallTimeout, deadline time.Duration
+	if limit != nil {
+		stallTimeout = limit.StallTimeout
+		deadline = limit.Deadline(size)
+	}
+	if stallTimeout <= 0 && deadline <= 0 {
+		return watcher
+	}
+
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(transferCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-watcher.done:
+				return
+			case now := <-ticker.C:
+				var err *TransferError
+				if stallTimeout > 0 && now.Sub(time.Unix(0, watcher.last.Load())) > stallTimeout {
+					err = &TransferError{Path: path, Reason: "stalled", Timeout: stallTimeout}
+				} else if deadline > 0 && now.Sub(start) > deadline {
+					err = &TransferError{Path: path, Reason: "deadline exceeded", Timeout: deadline}
+				}
+				if err != nil {
+					watcher.err.Store(err)
+					abort()
+					return
+				}
+			}
+		}
+	}()
+
+	return watcher
+}
+
+func (watcher *transferWatcher) progress() {
+	watcher.last.Store(time.Now().UnixNano())
+}
+
+// Err 는 전송을 중단했으면 그 이유를 반환하고 아니면 err 를 그대로 반환
+func (watcher *transferWatcher) Err(err error) error {
+	if transferErr := watcher.err.Load(); transferErr != nil {
+		return transferErr
+	}
+	return err
+}
+
+func (watcher *transferWatcher) Stop() {
+	watcher.stopOnce.Do(func() {
+		close(watcher.done)
+	})
+}
+
+// Reader 는 읽을 때마다 진행 상황을 기록하는 reader 를 반환
+func (watcher *transferWatcher) Reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, watcher: watcher}
+}
+
+// BodyReader 는 요청 본문용 reader 를 반환하며 끝까지 읽으면 응답을 기다리는 동안에는 감시하지 않음
+func (watcher *transferWatcher) BodyReader(r io.Reader) io.Reader {
+	return &progressReader{r: r, watcher: watcher, stopOnEOF: true}
+}
+
+type progressReader struct {
+	r         io.Reader
+	watcher   *transferWatcher
+	stopOnEOF bool
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.watcher.progress()
+	}
+	if err == io.EOF && pr.stopOnEOF {
+		pr.watcher.Stop()
+	}
+	return n, err
+}
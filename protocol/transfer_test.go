@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestWatchTransferAbort(t *testing.T) {
+	const content = "0123456789abcdefghij"
+
+	tests := []struct {
+		name       string
+		limit      *TransferLimit
+		handler    func(w http.ResponseWriter, r *http.Request)
+		wantReason string // 비어 있으면 중단되지 않음
+		wantTemp   string // 중단됐을 때 남아 있어야 하는 .download 파일 내용
+	}{
+		{
+			name:  "stalled body",
+			limit: &TransferLimit{StallTimeout: 500 * time.Millisecond},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				_, _ = w.Write([]byte(content[:5]))
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			},
+			wantReason: "stalled",
+			wantTemp:   content[:5],
+		},
+		{
+			name:  "deadline exceeded",
+			limit: &TransferLimit{BaseTimeout: 500 * time.Millisecond, MinRate: 1 << 20},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				for i := 0; i < len(content); i++ {
+					select {
+					case <-r.Context().Done():
+						return
+					case <-time.After(200 * time.Millisecond):
+					}
+					_, _ = w.Write([]byte{content[i]})
+					w.(http.Flusher).Flush()
+				}
+			},
+			wantReason: "deadline exceeded",
+		},
+		{
+			name:  "finished",
+			limit: &TransferLimit{StallTimeout: 500 * time.Millisecond, BaseTimeout: 5 * time.Second, MinRate: 1 << 20},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(content))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer server.Close()
+
+			destPath := filepath.Join(t.TempDir(), "a.jpg")
+			file := &File{Name: "a.jpg", Path: "/photo/a.jpg"}
+			file.Additional.Size = uint64(len(content))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, _, err := downloadFile(ctx, tt.limit, file, destPath, func(ctx context.Context, offset int64) (*http.Response, error) {
+				return requestRange(ctx, server.Client(), "SYNO.FileStation.Download", server.URL, offset)
+			})
+
+			if len(tt.wantReason) == 0 {
+				if err != nil {
+					t.Fatalf("downloadFile() error = %v", err)
+				}
+				return
+			}
+
+			var transferErr *TransferError
+			if !errors.As(err, &transferErr) {
+				t.Fatalf("downloadFile() error = %v, want *TransferError", err)
+			}
+			if transferErr.Reason != tt.wantReason {
+				t.Errorf("downloadFile() reason = %q, want %q", transferErr.Reason, tt.wantReason)
+			}
+			if !IsRetryable(ctx, err) {
+				t.Errorf("IsRetryable(%v) = false, want true", err)
+			}
+			data, _ := os.ReadFile(destPath + ".download")
+			if !strings.HasPrefix(content, string(data)) || (len(tt.wantTemp) != 0 && string(data) != tt.wantTemp) {
+				t.Errorf("%s.download file = %q, want prefix of %q", destPath, data, content)
+			}
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package protocol
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
+	"log"
+	"net/http"
 	"os"
+	"time"
 )
 
 type ConnectionInfo struct {
@@ -11,6 +18,44 @@ type ConnectionInfo struct {
 	Port     int
 	Username string
 	Password string
+	Scheme   string
+	TLS      *TLSInfo
+	OTP      *OTPInfo
+	Timeout  *TimeoutInfo
+	Transfer *TransferLimit
+	SSHAuth  *SSHAuthInfo
+	HostKey  *HostKeyInfo
+}
+
+// SizeMismatchError 는 전송된 파일 크기가 원본과 다를 때 발생
+type SizeMismatchError struct {
+	Path     string
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("%s size mismatch (expected: %d, actual: %d)", e.Path, e.Expected, e.Actual)
+}
+
+// Retryable 은 남은 부분을 다시 받으면 되므로 항상 true
+func (e *SizeMismatchError) Retryable() bool {
+	return true
+}
+
+// StatusError 는 다운로드 요청에 파일이 아닌 HTTP 응답이 왔을 때 발생
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d %s from %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// Retryable 은 서버 오류 및 요청이 너무 많은 경우에만 true
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
 }
 
 func IsSameFileSize(targetFile string, compareFile fs.FileInfo) (bool, error) {
@@ -30,3 +75,35 @@ func FileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	return !os.IsNotExist(err)
 }
+
+// FileMD5 는 로컬 파일의 MD5 해시를 hex 문자열로 반환
+func FileMD5(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("fail to open %s file: %v", filePath, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("fail to close %s file: %v", filePath, err)
+		}
+	}()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("fail to read %s file: %v", filePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// SleepContext 는 d 만큼 기다리며 ctx 가 취소되면 바로 반환
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"gopkg.in/yaml.v2"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const scanStateFilename = "scan_state.yaml"
+
+// 증분 검색 시 NAS 와 시간 차이를 고려해 겹쳐서 검색할 시간
+const scanOverlap = 1 * time.Hour
+
+type scanState struct {
+	Sources       map[string]*sourceScanState `yaml:"sources"`
+	PostSyncSince int64                       `yaml:"post_sync_since,omitempty"`
+
+	// 원본 경로를 하나만 지원하던 이전 형식
+	LastScan     int64 `yaml:"last_scan,omitempty"`
+	LastFullScan int64 `yaml:"last_full_scan,omitempty"`
+}
+
+type sourceScanState struct {
+	LastScan     int64 `yaml:"last_scan"`
+	LastFullScan int64 `yaml:"last_full_scan"`
+}
+
+func readScanState() (*scanState, error) {
+	state := &scanState{Sources: make(map[string]*sourceScanState)}
+	statePath := filepath.Join(config.LocalPath, scanStateFilename)
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("fail to read %s scan state file: %v", statePath, err)
+	}
+
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal %s scan state file: %v", statePath, err)
+	}
+	if state.Sources == nil {
+		state.Sources = make(map[string]*sourceScanState)
+	}
+	state.migrate()
+	return state, nil
+}
+
+func writeScanState(state *scanState) error {
+	statePath := filepath.Join(config.LocalPath, scanStateFilename)
+
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("fail to marshal %s scan state file: %v", statePath, err)
+	}
+	if err := os.WriteFile(statePath, data, 0644); err != nil {
+		return fmt.Errorf("fail to write %s file: %v", statePath, err)
+	}
+	return nil
+}
+
+// migrate 는 이전 형식의 검색 상태를 원본 경로가 하나일 때 그 경로로 옮김
+func (state *scanState) migrate() {
+	if state.LastScan == 0 && state.LastFullScan == 0 {
+		return
+	}
+	if config.Synology != nil && len(config.Synology.Paths) == 1 {
+		sourcePath := config.Synology.Paths[0].Path
+		if _, ok := state.Sources[sourcePath]; !ok {
+			state.Sources[sourcePath] = &sourceScanState{LastScan: state.LastScan, LastFullScan: state.LastFullScan}
+			log.Printf("migrate old scan state to %s source path", sourcePath)
+		}
+	} else {
+		log.Print("old scan state can not be matched to multiple source paths, full scan will run")
+	}
+	state.LastScan, state.LastFullScan = 0, 0
+}
+
+// source 는 원본 경로의 검색 상태를 반환
+func (state *scanState) source(sourcePath string) *sourceScanState {
+	sourceState, ok := state.Sources[sourcePath]
+	if !ok {
+		sourceState = &sourceScanState{}
+		state.Sources[sourcePath] = sourceState
+	}
+	return sourceState
+}
+
+// postSyncSince 는 post sync 를 켠 시간을 반환하며 처음 켰으면 now 를 기록
+func postSyncSince(now time.Time) (int64, error) {
+	state, err := readScanState()
+	if err != nil {
+		return 0, err
+	}
+	if state.PostSyncSince == 0 {
+		state.PostSyncSince = now.Unix()
+		if err := writeScanState(state); err != nil {
+			return 0, err
+		}
+		log.Printf("post sync enabled, files sent before %s are not processed", now)
+	}
+	return state.PostSyncSince, nil
+}
+
+// resetPostSyncSince 는 post sync 를 끄면 켠 시간을 지워 다시 켰을 때 그때부터 처리하도록 함
+func resetPostSyncSince() error {
+	state, err := readScanState()
+	if err != nil {
+		return err
+	}
+	if state.PostSyncSince == 0 {
+		return nil
+	}
+	state.PostSyncSince = 0
+	return writeScanState(state)
+}
+
+// needFullScan 은 전체 검색 주기가 지났는지 확인
+func (state *sourceScanState) needFullScan(now time.Time) bool {
+	if !config.IncrementalScan || state.LastScan == 0 || state.LastFullScan == 0 {
+		return true
+	}
+	return now.Sub(time.Unix(state.LastFullScan, 0)) >= time.Duration(config.FullScanCycle)*time.Hour
+}
+
+// pruneDeletedFiles 는 전체 검색 결과에 없는 파일을 로컬과 메타데이터에서 삭제
+func pruneDeletedFiles(folderPath string, fileList *protocol.FileListResponse) error {
+	seen := make(map[string]bool)
+	var unknownDirs []string
+	collectSearchedFiles(fileList, seen, &unknownDirs)
+
+	return filepath.Walk(filepath.Join(config.LocalPath, folderPath), func(targetPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != config.YAML.Filename {
+			return nil
+		}
+
+		targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
+		if err != nil {
+			return err
+		}
+
+		for filePath := range targetMetadata {
+			if seen[filePath] || isUnderDirs(filePath, unknownDirs) {
+				continue
+			}
+
+			// 원본이 삭제된 파일
+			for _, removePath := range []string{filePath, filePath + ".download"} {
+				if protocol.FileExists(removePath) {
+					if err := os.Remove(removePath); err != nil {
+						return fmt.Errorf("fail to %s remove file: %v", removePath, err)
+					}
+				}
+			}
+			if err := protocol.DeleteMetadata(filePath, config.YAML.Filename); err != nil {
+				return err
+			}
+			log.Printf("%s was deleted from source, remove local file", filePath)
+		}
+		return nil
+	})
+}
+
+func collectSearchedFiles(fileList *protocol.FileListResponse, seen map[string]bool, unknownDirs *[]string) {
+	for _, file := range fileList.Data.Files {
+		localPath := filepath.Join(config.LocalPath, file.Path)
+		if !file.IsDir {
+			seen[localPath] = true
+			continue
+		}
+
+		// 검색하지 못한 폴더는 삭제 여부를 알 수 없음
+		if file.List == nil {
+			*unknownDirs = append(*unknownDirs, localPath)
+			continue
+		}
+		collectSearchedFiles(file.List, seen, unknownDirs)
+	}
+}
+
+func isUnderDirs(filePath string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(filePath, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,28 +6,28 @@ import (
 	"path/filepath"
 )
 
-func GetInitStatus(folderPath string) (map[string]metadata.FileMetadata, error) {
-	return searchMetadata(folderPath, metadata.Init)
+func GetInitStatus(folderPath, filename string) (map[string]metadata.FileMetadata, error) {
+	return searchMetadata(folderPath, filename, metadata.Init)
 }
 
-func GetNotSentStatus(folderPath string) (map[string]metadata.FileMetadata, error) {
-	return searchMetadata(folderPath, metadata.NotSent)
+func GetNotSentStatus(folderPath, filename string) (map[string]metadata.FileMetadata, error) {
+	return searchMetadata(folderPath, filename, metadata.NotSent)
 }
 
-func GetSentStatus(folderPath string) (map[string]metadata.FileMetadata, error) {
-	return searchMetadata(folderPath, metadata.Sent)
+func GetSentStatus(folderPath, filename string) (map[string]metadata.FileMetadata, error) {
+	return searchMetadata(folderPath, filename, metadata.Sent)
 }
 
-func GetFailedStatus(folderPath string) (map[string]metadata.FileMetadata, error) {
-	return searchMetadata(folderPath, metadata.Failed)
+func GetFailedStatus(folderPath, filename string) (map[string]metadata.FileMetadata, error) {
+	return searchMetadata(folderPath, filename, metadata.Failed)
 }
 
-func searchMetadata(folderPath string, status metadata.FileTransferStatus) (map[string]metadata.FileMetadata, error) {
+func searchMetadata(folderPath, filename string, status metadata.FileTransferStatus) (map[string]metadata.FileMetadata, error) {
 	result := make(map[string]metadata.FileMetadata)
 
 	err := filepath.Walk(folderPath, func(targetPath string, info os.FileInfo, err error) error {
 		if info.IsDir() {
-			if data, err := metadata.ReadMetadata(targetPath); err == nil {
+			if data, err := metadata.ReadMetadata(targetPath, filename); err == nil {
 				for key, value := range data {
 					if value.Status == string(status) {
 						result[key] = value
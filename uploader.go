@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/lolgopher/synology-filesync/protocol"
 	"github.com/pkg/errors"
@@ -11,26 +12,64 @@ import (
 	"time"
 )
 
-func uploadRemote(info *protocol.ConnectionInfo) {
+// uploader 는 업로드 방식별 파일 전송 방법
+type uploader interface {
+	// Send 는 파일을 업로드 기본 경로 아래 destPath 로 전송하고 전송한 크기를 반환하며 같은 파일이 이미 있으면 0 을 반환
+	Send(ctx context.Context, targetPath, destPath string, metadata protocol.FileMetadata) (int64, error)
+	Close(ctx context.Context) error
+}
+
+func newUploader(ctx context.Context, info *protocol.ConnectionInfo) (uploader, error) {
+	switch config.UploadType {
+	case "synology":
+		return newSynologyUploader(ctx, info)
+	default:
+		return newSFTPUploader(info)
+	}
+}
+
+func uploadRemote(ctx context.Context, info *protocol.ConnectionInfo) {
 	wg.Add(1)
 	go func() {
 		defer func() {
 			wg.Done()
 		}()
 
-		// ssh client 생성
-		client, err := protocol.NewSFTPClient(info)
+		// 원본 파일 post sync client 생성
+		var ps *postSyncer
+		if config.PostSync != nil && config.PostSync.Action != "none" {
+			since, err := postSyncSince(time.Now())
+			if err != nil {
+				fatalf(ctx, "fail to read post sync start time: %v", err)
+			}
+			ps, err = newPostSyncer(ctx, newSynologyInfo(config.Synology), since)
+			if err != nil {
+				fatalf(ctx, "fail to make post sync client: %v", err)
+			}
+			defer func() {
+				if err := ps.Close(ctx); err != nil {
+					log.Printf("fail to close post sync client: %v", err)
+				}
+			}()
+		} else if err := resetPostSyncSince(); err != nil {
+			log.Printf("fail to reset post sync start time: %v", err)
+		}
+
+		// upload client 생성
+		client, err := newUploader(ctx, info)
 		if err != nil {
-			log.Fatalf("fail to make srtp client: %v", err)
+			fatalf(ctx, "fail to make %s upload client: %v", config.UploadType, err)
 		}
 		defer func() {
-			if err := client.Close(); err != nil {
-				log.Printf("fail to close sftp client: %v", err)
+			if err := client.Close(ctx); err != nil {
+				log.Printf("fail to close %s upload client: %v", config.UploadType, err)
 			}
 		}()
 
-		if err := searchLocal(client, filepath.Join(config.LocalPath, config.Synology.Path)); err != nil {
-			log.Fatalf("fail to search local: %v", err)
+		for _, source := range config.sourcePaths() {
+			if err := searchLocal(ctx, client, ps, source); err != nil {
+				fatalf(ctx, "fail to search local %s: %v", source.LocalPath(), err)
+			}
 		}
 	}()
 	log.Print("Upload...")
@@ -39,14 +78,19 @@ func uploadRemote(info *protocol.ConnectionInfo) {
 	log.Print("Done!")
 }
 
-func searchLocal(sftp *protocol.SFTPClient, folderPath string) error {
+func searchLocal(ctx context.Context, client uploader, ps *postSyncer, source *SourcePath) error {
 	// 파일 시스템에서 파일 검색
-	err := filepath.Walk(folderPath, func(targetPath string, info os.FileInfo, err error) error {
+	err := filepath.Walk(source.LocalPath(), func(targetPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		// 종료 중이면 더 이상 전송하지 않음
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		if !info.IsDir() && info.Name() != "metadata.yaml" {
+		// 이어받는 중인 파일과 include/exclude 규칙에 맞지 않는 파일은 제외
+		if !info.IsDir() && info.Name() != "metadata.yaml" && !strings.HasSuffix(info.Name(), ".download") && source.Match(info.Name()) {
 			// 전송에 성공했는지 확인
 			targetMetadata, err := protocol.ReadMetadata(filepath.Dir(targetPath), config.YAML.Filename)
 			if err != nil {
@@ -64,13 +108,18 @@ func searchLocal(sftp *protocol.SFTPClient, folderPath string) error {
 				return nil
 			case protocol.Sent:
 				log.Printf("%s has already been sent", targetPath)
+
+				// 이전 주기에 실패한 post sync 다시 시도
+				if ps != nil {
+					return ps.Run(ctx, source, targetPath, metadata)
+				}
 				return nil
 			case protocol.Failed:
 				log.Printf("%s sent failed", targetPath)
 				return nil
 			case protocol.NotSent:
 				var result protocol.FileTransferStatus
-				if size, err := sendFileOverSFTP(&sftp, targetPath); err != nil {
+				if size, err := client.Send(ctx, targetPath, source.RemotePath(targetPath), metadata); err != nil {
 					// 전송에 실패했을때
 					result = protocol.Failed
 					log.Printf("fail to %s not sent file: %v", targetPath, err)
@@ -86,10 +135,26 @@ func searchLocal(sftp *protocol.SFTPClient, folderPath string) error {
 					}
 				}
 
-				if err := protocol.WriteMetadata(targetPath, config.YAML.Filename, 0, result); err != nil {
+				if result == protocol.Sent {
+					metadata.SentAt = time.Now().Unix()
+				}
+				if err := protocol.UpdateMetadata(targetPath, config.YAML.Filename, func(m *protocol.FileMetadata) {
+					m.Status = string(result)
+					m.SentAt = metadata.SentAt
+					if result == protocol.Sent {
+						m.Replace = false
+					}
+				}); err != nil {
+					return err
+				}
+				if result == protocol.Sent && ps != nil {
+					if err := ps.Run(ctx, source, targetPath, metadata); err != nil {
+						return err
+					}
+				}
+				if err := protocol.SleepContext(ctx, time.Duration(config.UploadDelay)*time.Second); err != nil {
 					return err
 				}
-				time.Sleep(time.Duration(config.UploadDelay) * time.Second)
 			default:
 				log.Printf("%s is unknown status", metadata.Status)
 				return nil
@@ -102,13 +167,44 @@ func searchLocal(sftp *protocol.SFTPClient, folderPath string) error {
 	return err
 }
 
-func sendFileOverSFTP(sftp **protocol.SFTPClient, targetPath string) (int, error) {
+type sftpUploader struct {
+	client *protocol.SFTPClient
+}
+
+func newSFTPUploader(info *protocol.ConnectionInfo) (*sftpUploader, error) {
+	// ssh client 생성
+	client, err := protocol.NewSFTPClient(info)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make sftp client")
+	}
+	return &sftpUploader{client: client}, nil
+}
+
+func (u *sftpUploader) Send(ctx context.Context, targetPath, destPath string, metadata protocol.FileMetadata) (int64, error) {
+	destPath = filepath.Join(config.SSH.Path, destPath)
+	options := protocol.SendOptions{Resume: config.SSH.Resume, Overwrite: metadata.Replace}
+
+	// 원본 파일 시간 적용
+	if config.PreserveTime {
+		options.ModTime = time.Unix(metadata.ModTime, 0)
+		if metadata.ModTime == 0 {
+			if info, err := os.Stat(targetPath); err == nil {
+				options.ModTime = info.ModTime()
+			}
+		}
+	}
+
+	return sendFileOverSFTP(ctx, &u.client, targetPath, destPath, options)
+}
+
+func (u *sftpUploader) Close(_ context.Context) error {
+	return u.client.Close()
+}
+
+func sendFileOverSFTP(ctx context.Context, sftp **protocol.SFTPClient, targetPath, destPath string, options protocol.SendOptions) (int64, error) {
 	var lastError error
-	size := 0
+	var size int64
 	for i := 0; i < config.UploadRetryCount; i++ {
-		destPath, _ := strings.CutPrefix(targetPath, config.LocalPath)
-		destPath = filepath.Join(config.SSH.Path, destPath)
-
 		// 용량 확인
 		targetFileInfo, err := os.Stat(targetPath)
 		if err != nil {
@@ -128,40 +224,44 @@ func sendFileOverSFTP(sftp **protocol.SFTPClient, targetPath string) (int, error
 					"\tspare space: %d\n)", targetSize, freeSize, config.SpareSpace)
 				log.Printf(lastError.Error())
 				log.Printf("retrying...")
-				time.Sleep(time.Duration(config.UploadRetryDelay) * time.Second)
+				if err := protocol.SleepContext(ctx, time.Duration(config.UploadRetryDelay)*time.Second); err != nil {
+					return 0, err
+				}
 				continue
 			}
 		}
 
 		// 파일 전송
-		size, err = (*sftp).SendFile(targetPath, destPath)
+		size, err = (*sftp).SendFile(targetPath, destPath, options)
 		if err != nil {
 			lastError = fmt.Errorf("fail to %s send file over sftp: %v", targetPath, err)
 			log.Print(lastError.Error())
 
+			// 전송이 멈춰 중단했으면 연결이 끊긴 상태
+			var transferErr *protocol.TransferError
 			errStr := errors.Cause(err).Error()
-			if strings.Contains(errStr, "connection lost") ||
+			if errors.As(err, &transferErr) ||
+				strings.Contains(errStr, "connection lost") ||
 				strings.Contains(errStr, "no route to host") {
 				// ssh client 재생성
 				newSFTP, err := protocol.NewSFTPClient((*sftp).ConnInfo)
 				if err != nil {
-					log.Fatalf("fail to make sftp client: %v", err)
+					fatalf(ctx, "fail to make sftp client: %v", err)
 				} else {
 					_ = (*sftp).Close()
-					sftp = &newSFTP
+					*sftp = newSFTP
 				}
 			}
 
-			// 기존 파일 삭제
-			if err := (*sftp).RemoveFile(destPath); err != nil {
-				log.Printf("fail to remove %s remote file: %v", destPath, err)
-			}
 			log.Printf("retrying...")
-			time.Sleep(time.Duration(config.UploadRetryDelay) * time.Second)
+			if err := protocol.SleepContext(ctx, time.Duration(config.UploadRetryDelay)*time.Second); err != nil {
+				return 0, err
+			}
 		} else {
-			break
+			// 이전 시도의 오류는 무시
+			return size, nil
 		}
 	}
 
-	return size, lastError
+	return 0, lastError
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lolgopher/synology-filesync/protocol"
+	"github.com/pkg/errors"
+	"log"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+type synologyUploader struct {
+	client *protocol.SynologyClient
+}
+
+func newSynologyUploader(ctx context.Context, info *protocol.ConnectionInfo) (*synologyUploader, error) {
+	// synology client 생성
+	client, err := protocol.NewSynologyClient(ctx, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to make synology client")
+	}
+	if _, err := client.API("SYNO.FileStation.Upload"); err != nil {
+		if err := client.Logout(ctx); err != nil {
+			log.Printf("fail to logout synology client: %v", err)
+		}
+		return nil, err
+	}
+	return &synologyUploader{client: client}, nil
+}
+
+func (u *synologyUploader) Send(ctx context.Context, targetPath, destPath string, metadata protocol.FileMetadata) (int64, error) {
+	destPath = path.Join(config.SynologyUpload.Path, filepath.ToSlash(destPath))
+	overwrite := protocol.OverwritePolicy(config.SynologyUpload.Overwrite)
+	// 이미 전송한 파일이 바뀌었으면 원격지의 이전 파일을 덮어씀
+	if metadata.Replace {
+		overwrite = protocol.Overwrite
+	}
+
+	var lastError error
+	for i := 0; i < config.UploadRetryCount; i++ {
+		// 파일 전송
+		size, err := u.client.UploadFile(ctx, targetPath, destPath, overwrite)
+		if err == nil {
+			return size, nil
+		}
+
+		lastError = fmt.Errorf("fail to %s send file over synology: %v", targetPath, err)
+		log.Print(lastError.Error())
+		if !protocol.IsRetryable(ctx, err) {
+			break
+		}
+		log.Printf("retrying...")
+		if err := protocol.SleepContext(ctx, time.Duration(config.UploadRetryDelay)*time.Second); err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, lastError
+}
+
+func (u *synologyUploader) Close(ctx context.Context) error {
+	return u.client.Logout(ctx)
+}